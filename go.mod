@@ -5,4 +5,5 @@ go 1.13
 require (
 	github.com/golang/protobuf v1.4.2
 	google.golang.org/protobuf v1.25.0
+	modernc.org/sqlite v1.20.4
 )
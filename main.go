@@ -1,23 +1,108 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jadugnap/golang-fpl-101/pkg/autosub"
+	"github.com/jadugnap/golang-fpl-101/pkg/backtest"
+	"github.com/jadugnap/golang-fpl-101/pkg/captaincy"
+	"github.com/jadugnap/golang-fpl-101/pkg/chips"
 	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/diff"
 	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
 	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+	"github.com/jadugnap/golang-fpl-101/pkg/league"
+	"github.com/jadugnap/golang-fpl-101/pkg/live"
+	"github.com/jadugnap/golang-fpl-101/pkg/optimizer"
+	"github.com/jadugnap/golang-fpl-101/pkg/ownership"
+	"github.com/jadugnap/golang-fpl-101/pkg/planner"
+	"github.com/jadugnap/golang-fpl-101/pkg/price"
+	"github.com/jadugnap/golang-fpl-101/pkg/projection"
+	"github.com/jadugnap/golang-fpl-101/pkg/storage"
 )
 
 func main() {
+	concurrency := flag.Int("concurrency", element.DefaultConcurrency, "max concurrent element-summary requests")
+	rps := flag.Float64("rps", 5, "max requests per second shared by every client, 0 to disable")
+	cacheDir := flag.String("cache-dir", "cache_out", "directory of the on-disk response cache")
+	noCache := flag.Bool("no-cache", false, "always fetch from network, bypassing the response cache")
+	recordDir := flag.String("record", "", "save every response into this fixture directory")
+	replayDir := flag.String("replay", "", "serve every response from this fixture directory, offline")
+	tapeVersion := flag.String("tape-version", "", "fixture version, defaults to today on -record and latest on -replay")
+	tickerGameweeks := flag.Int("ticker-gameweeks", 6, "number of upcoming gameweeks in the fixture difficulty ticker")
+	liveGameweek := flag.Int("live", 0, "fetch live points of this gameweek instead of the full pipeline")
+	liveInterval := flag.Duration("live-interval", 0, "re-fetch live points on this interval until interrupted, 0 to fetch once")
+	entryIDs := flag.String("entries", "", "comma separated entry (manager) IDs to export picks and history of")
+	classicIDs := flag.String("classic", "", "comma separated classic league IDs to crawl")
+	h2hIDs := flag.String("h2h", "", "comma separated head-to-head league IDs to crawl")
+	me := flag.Int("me", 0, "your entry ID, to export the swing table against every crawled league")
+	returns := flag.Int("returns", 6, "points returned by a player in the swing table")
+	optimizeColumn := flag.String("optimize", "", "team.Player score column to pick the best squad by, e.g. TotalPoints or Form")
+	benchWeight := flag.Float64("bench-weight", 0.1, "weight (0 to 1) of bench score in the best squad")
+	planGameweeks := flag.Int("plan-gameweeks", 0, "plan transfers of every -entries over this many upcoming gameweeks, 0 to skip")
+	planColumn := flag.String("plan-column", "", "team.Player column projected per fixture by the transfer planner, the projection model when empty")
+	projectGameweeks := flag.Int("project-gameweeks", 5, "export expected points of this many upcoming gameweeks, 0 to skip")
+	chipGameweeks := flag.Int("chip-gameweeks", 0, "simulate every available chip of every -entries over this many upcoming gameweeks, 0 to skip")
+	captainMode := flag.String("captain-mode", "safe", "rank captains of every -entries as safe or differential, empty to skip")
+	priceDir := flag.String("price-dir", "", "directory of the daily price snapshots to track prices in, e.g. price_out")
+	storageKind := flag.String("storage", "csv", "where players, teams, fixtures, history and price snapshots go: csv, sqlite or both, every other output is csv")
+	sqlitePath := flag.String("sqlite-path", "fpl.db", "sqlite database of every run with -storage sqlite or both")
+	diffPaths := flag.String("diff", "", "old,new stored bootstrap-static snapshots (json files, -record versions or -sqlite-path@run_at) to report the changes between, offline")
+	diffJSON := flag.String("diff-json", "fpl-diff.json", "file of the -diff report as json")
+	floatPrecision := flag.Int("float-precision", csv.FloatPrecision, "digits after the point of float csv columns, -1 for the fewest exact ones")
+	runBacktest := flag.Bool("backtest", false, "replay every finished gameweek to score the projection model against baselines")
+	flag.Parse()
+	csv.FloatPrecision = *floatPrecision
+
+	ctx := context.Background()
 	start := time.Now()
 	defer func() {
 		log.Printf("Took %v overall to execute main()\n", time.Since(start))
 	}()
+	// compare two stored snapshots only, without fetching anything
+	if *diffPaths != "" {
+		if err := runDiff(*diffPaths, *diffJSON); err != nil {
+			log.Println("error runDiff():", err)
+		}
+		return
+	}
+
+	store, err := openStore(*storageKind, *sqlitePath, start)
+	if err != nil {
+		log.Println("error openStore():", err)
+		return
+	}
+	defer store.Close()
+
 	// define generic client
 	genCli := client.GenericClient{
 		HTTPClient: http.Client{Timeout: time.Second * 10},
+		Limiter:    client.NewLimiter(*rps, 1),
+	}
+	if !*noCache {
+		genCli.Cache = client.NewCache(*cacheDir)
+	}
+	switch {
+	case *replayDir != "":
+		genCli.Tape, err = client.NewTape(*replayDir, *tapeVersion, client.Replay)
+	case *recordDir != "":
+		genCli.Tape, err = client.NewTape(*recordDir, *tapeVersion, client.Record)
+	}
+	if err != nil {
+		log.Println("error client.NewTape():", err)
+		return
 	}
 
 	// define global FPL instance
@@ -26,15 +111,45 @@ func main() {
 	}
 	fplInfo.Client.Endpoint = fpl.Endpoint
 	// get bootstrap-static data
-	fplInfo.GetFplResponseToCsv()
+	fplInfo.GetFplResponseToCsv(ctx)
 	if len(fplInfo.Res.Players) == 0 {
 		log.Println("error executing getFplResponse().")
 		return
 	}
 
+	// snapshot today's prices, export rises & falls so far and tonight's predicted ones
+	if *priceDir != "" {
+		if err := trackPrices(&fplInfo, *priceDir); err != nil {
+			log.Println("error trackPrices():", err)
+		}
+	}
+
+	// get live gameweek data only, optionally polling during matches
+	if *liveGameweek > 0 {
+		if err := runLive(ctx, genCli, &fplInfo, *liveGameweek, *liveInterval, parseIDs(*entryIDs)); err != nil {
+			log.Println("error executing runLive():", err)
+		}
+		return
+	}
+
+	// get fixtures data & difficulty ticker
+	fixturesInfo := fixtures.Fixtures{
+		Client:   genCli,
+		Registry: fplInfo.Registry,
+	}
+	fixturesInfo.Client.Endpoint = fixtures.Endpoint
+	if err := fixturesInfo.GetFixturesToCsv(ctx); err != nil {
+		log.Println("error GetFixturesToCsv():", err)
+	}
+	if err := fixturesInfo.TickerToCsv(fplInfo.NextEvent(), *tickerGameweeks); err != nil {
+		log.Println("error TickerToCsv():", err)
+	}
+
 	// define global Element instance
 	eInfo := element.Element{
-		Client: genCli,
+		Client:      genCli,
+		Registry:    fplInfo.Registry,
+		Concurrency: *concurrency,
 	}
 	eInfo.Client.Endpoint = element.RawEndpoint
 	// get necessary data from fplInfo
@@ -42,9 +157,395 @@ func main() {
 		eInfo.PlayerIDlist = append(eInfo.PlayerIDlist, p.ID)
 	}
 	// get element-summary data
-	eInfo.GetElementSummaryToCsv()
+	if err := eInfo.GetElementSummaryToCsv(ctx); err != nil {
+		log.Println("error GetElementSummaryToCsv():", err)
+	}
 
 	// get necessary data from eInfo
 	fplInfo.Team2Gw2Points = eInfo.Team2Gw2Points
-	fplInfo.ToCsv()
+	if err := fplInfo.ToCsv(); err != nil {
+		log.Println("error fplInfo.ToCsv():", err)
+	}
+	if err := saveRun(store, &fplInfo, &fixturesInfo, &eInfo, start); err != nil {
+		log.Println("error saveRun():", err)
+	}
+
+	// pick the best squad & XI under the official budget, position and club rules
+	if *optimizeColumn != "" {
+		if err := optimizer.SquadToCsv(ctx, &fplInfo, *optimizeColumn, *benchWeight); err != nil {
+			log.Println("error optimizer.SquadToCsv():", err)
+		}
+	}
+
+	// project expected points of upcoming gameweeks
+	model := &projection.Model{
+		Players:    fplInfo.Res.Players,
+		Teams:      fplInfo.Res.Teams,
+		ID2History: eInfo.ID2History,
+		Fixtures:   &fixturesInfo,
+		Registry:   fplInfo.Registry,
+	}
+	if *projectGameweeks > 0 {
+		if err := model.ToCsv(upcomingGameweeks(&fplInfo, *projectGameweeks)); err != nil {
+			log.Println("error model.ToCsv():", err)
+		}
+	}
+	if *runBacktest {
+		replayed := *model
+		bt := backtest.Backtester{
+			Players:    fplInfo.Res.Players,
+			ID2History: eInfo.ID2History,
+			Registry:   fplInfo.Registry,
+		}
+		for gw := 2; gw <= fplInfo.CurrentEvent(); gw++ {
+			bt.Gameweeks = append(bt.Gameweeks, gw)
+		}
+		results := bt.Run(
+			backtest.ModelProjector{Model: &replayed},
+			backtest.NewFormProjector(eInfo.ID2History, 4),
+			backtest.NewPointsPerGameProjector(eInfo.ID2History),
+		)
+		if err := backtest.ToCsv(results); err != nil {
+			log.Println("error backtest.ToCsv():", err)
+		}
+	}
+
+	// get entry data joined to players
+	var project planner.Projection = model.Project
+	if *planColumn != "" {
+		if project, err = planner.ColumnProjection(fplInfo.Res.Players, *planColumn, &fixturesInfo); err != nil {
+			log.Println("error planner.ColumnProjection():", err)
+			return
+		}
+	}
+	entries := []*entry.Entry{}
+	for _, id := range parseIDs(*entryIDs) {
+		entryInfo := entry.Entry{
+			Client:     genCli,
+			ID:         id,
+			Players:    fplInfo.Res.Players,
+			ID2History: eInfo.ID2History,
+		}
+		entryInfo.Client.Endpoint = entry.RawEndpoint
+		if err := entryInfo.GetEntryToCsv(ctx, nil); err != nil {
+			log.Printf("error GetEntryToCsv() on entry %d: %+v\n", id, err)
+			continue
+		}
+		err := autosub.EntryToCsv(&entryInfo, fplInfo.Registry, func(gw int) map[int]autosub.Appearance {
+			return autosub.FromHistory(gw, &fixturesInfo, fplInfo.Res.Players, eInfo.ID2History)
+		})
+		if err != nil {
+			log.Printf("error autosub.EntryToCsv() on entry %d: %+v\n", id, err)
+		}
+		if *planGameweeks > 0 || *chipGameweeks > 0 {
+			// purchase prices behind selling prices, current prices without them
+			if err := entryInfo.FetchTransfers(ctx); err != nil {
+				log.Printf("error FetchTransfers() on entry %d: %+v\n", id, err)
+			}
+		}
+		if *planGameweeks > 0 {
+			if err := planEntry(&entryInfo, &fplInfo, project, *planGameweeks); err != nil {
+				log.Printf("error planEntry() on entry %d: %+v\n", id, err)
+			}
+		}
+		if *chipGameweeks > 0 {
+			if err := simulateChips(ctx, &entryInfo, &fplInfo, &fixturesInfo, project, *chipGameweeks); err != nil {
+				log.Printf("error simulateChips() on entry %d: %+v\n", id, err)
+			}
+		}
+		entries = append(entries, &entryInfo)
+	}
+
+	// get league standings & members' picks of every gameweek so far
+	leagueEntry2Picks := make(map[int]entry.PicksResponse)
+	gameweeks := []int{}
+	for gw := 1; gw <= fplInfo.CurrentEvent(); gw++ {
+		gameweeks = append(gameweeks, gw)
+	}
+	for _, leagueIDs := range []struct {
+		ids         string
+		rawEndpoint string
+	}{
+		{*classicIDs, league.RawClassicEndpoint},
+		{*h2hIDs, league.RawH2HEndpoint},
+	} {
+		for _, id := range parseIDs(leagueIDs.ids) {
+			leagueInfo := league.League{
+				Client:        genCli,
+				EntryClient:   genCli,
+				MatchesClient: genCli,
+				Registry:      fplInfo.Registry,
+				ID:            id,
+				Gameweeks:     gameweeks,
+				Concurrency:   *concurrency,
+			}
+			leagueInfo.Client.Endpoint = leagueIDs.rawEndpoint
+			leagueInfo.EntryClient.Endpoint = entry.RawEndpoint
+			leagueInfo.MatchesClient.Endpoint = league.RawH2HMatchesEndpoint
+			if err := leagueInfo.GetLeagueToCsv(ctx); err != nil {
+				log.Printf("error GetLeagueToCsv() on league %d: %+v\n", id, err)
+				continue
+			}
+			if err := ownership.LeagueToCsv(&leagueInfo, fplInfo.Res.Players, *me, *returns); err != nil {
+				log.Printf("error ownership.LeagueToCsv() on league %d: %+v\n", id, err)
+			}
+			for entryID, gw2Picks := range leagueInfo.Entry2Gw2Picks {
+				if picks, ok := gw2Picks[fplInfo.CurrentEvent()]; ok {
+					leagueEntry2Picks[entryID] = picks
+				}
+			}
+		}
+	}
+
+	// rank captains of every entry for the next gameweek against the crawled leagues
+	if *captainMode != "" {
+		mode, err := captaincy.ParseMode(*captainMode)
+		if err != nil {
+			log.Println("error captaincy.ParseMode():", err)
+			return
+		}
+		recommender := captaincy.Recommender{
+			Mode:             mode,
+			AdjustDifficulty: *planColumn != "",
+			Players:          fplInfo.Res.Players,
+			ID2History:       eInfo.ID2History,
+			Fixtures:         &fixturesInfo,
+			Registry:         fplInfo.Registry,
+			Projection:       project,
+		}
+		for _, e := range entries {
+			picks, ok := e.Gw2Picks[fplInfo.CurrentEvent()]
+			if !ok {
+				continue
+			}
+			rivals := ownership.Rivals(leagueEntry2Picks, e.ID)
+			recommender.EffectiveOwnership = ownership.ByPlayer(ownership.Calculate(fplInfo.CurrentEvent(), rivals))
+			candidates := recommender.Recommend(picks, fplInfo.NextEvent())
+			if err := recommender.EntryToCsv(e.ID, fplInfo.NextEvent(), candidates); err != nil {
+				log.Printf("error recommender.EntryToCsv() on entry %d: %+v\n", e.ID, err)
+			}
+		}
+	}
+}
+
+// parseIDs from a comma separated list, skipping invalid ones
+func parseIDs(list string) []int {
+	ids := []int{}
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			log.Printf("skipping invalid id %q\n", field)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// planEntry ranked transfer plans of e over the next gameweeks gameweeks
+func planEntry(e *entry.Entry, fplInfo *fpl.FPL, project planner.Projection, gameweeks int) error {
+	start, err := planner.FromEntry(e, fplInfo.CurrentEvent(), planner.DefaultMaxFreeTransfers)
+	if err != nil {
+		return err
+	}
+	upcoming := upcomingGameweeks(fplInfo, gameweeks)
+	p := planner.Planner{
+		Rules:      optimizer.NewRules(fplInfo.Res.GameSettings, fplInfo.Res.PlayerRoles),
+		SellOnFee:  fplInfo.Res.GameSettings.TransfersSellOnFee,
+		Players:    fplInfo.Res.Players,
+		Projection: project,
+	}
+	plans, err := p.Plans(start, upcoming, 10)
+	if err != nil {
+		return err
+	}
+	return planner.EntryToCsv(e.ID, plans, upcoming, fplInfo.Registry)
+}
+
+// simulateChips of e available over the next gameweeks gameweeks
+func simulateChips(ctx context.Context, e *entry.Entry, fplInfo *fpl.FPL, fixturesInfo *fixtures.Fixtures, project planner.Projection, gameweeks int) error {
+	start, err := planner.FromEntry(e, fplInfo.CurrentEvent(), planner.DefaultMaxFreeTransfers)
+	if err != nil {
+		return err
+	}
+	sim := chips.Simulator{
+		Rules:      optimizer.NewRules(fplInfo.Res.GameSettings, fplInfo.Res.PlayerRoles),
+		HalfEnd:    chips.HalfEnd(fplInfo.Res),
+		SellOnFee:  fplInfo.Res.GameSettings.TransfersSellOnFee,
+		Players:    fplInfo.Res.Players,
+		Fixtures:   fixturesInfo,
+		Projection: project,
+	}
+	timings, err := sim.Simulate(ctx, start, chips.Available(e.History, sim.HalfEnd), upcomingGameweeks(fplInfo, gameweeks))
+	if err != nil {
+		return err
+	}
+	return chips.EntryToCsv(e.ID, timings, chips.CrowdUsage(fplInfo.Res))
+}
+
+// csvTables of saveRun written as csv, players and teams as fpl.ToCsv used to
+var csvTables = map[string]string{
+	"players": "fpl-players/allteam",
+	"teams":   "fpl-teams",
+}
+
+// openStore of kind for saveRun, every other output is csv whatever the kind
+func openStore(kind, sqlitePath string, runAt time.Time) (storage.Store, error) {
+	csvStore := storage.CSV{Prefixes: csvTables}
+	switch kind {
+	case "csv":
+		return csvStore, nil
+	case "sqlite", "both":
+	default:
+		return nil, fmt.Errorf("unknown storage %q, want csv, sqlite or both", kind)
+	}
+	sqliteStore, err := storage.OpenSQLite(sqlitePath, runAt)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "both" {
+		return storage.Multi{csvStore, sqliteStore}, nil
+	}
+	return sqliteStore, nil
+}
+
+// saveRun players, teams, fixtures, gameweek history and a price snapshot into store
+func saveRun(store storage.Store, fplInfo *fpl.FPL, fixturesInfo *fixtures.Fixtures, eInfo *element.Element, runAt time.Time) error {
+	historyList := []element.History{}
+	for _, p := range fplInfo.Res.Players {
+		historyList = append(historyList, eInfo.ID2History[p.ID]...)
+	}
+	for _, t := range []struct {
+		name string
+		rows interface{}
+	}{
+		{"players", fplInfo.Res.Players},
+		{"teams", fplInfo.Res.Teams},
+		{"fixtures", fixturesInfo.Res},
+		{"history", historyList},
+		{"price_snapshots", price.FromResponse(fplInfo.Res, runAt).Players},
+	} {
+		if err := store.Save(t.name, t.rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackPrices of fplInfo into the daily snapshots of dir
+func trackPrices(fplInfo *fpl.FPL, dir string) error {
+	store := price.Store{Dir: dir}
+	if err := store.Save(price.FromResponse(fplInfo.Res, time.Now())); err != nil {
+		return err
+	}
+	snapshots, err := store.Load()
+	if err != nil {
+		return err
+	}
+	return price.Predictor{}.ToCsv(snapshots, fplInfo.Registry)
+}
+
+// upcomingGameweeks from the next event, at most n and none past the last event
+func upcomingGameweeks(fplInfo *fpl.FPL, n int) []int {
+	upcoming := []int{}
+	for gw := fplInfo.NextEvent(); gw < fplInfo.NextEvent()+n && gw <= len(fplInfo.Res.Events); gw++ {
+		upcoming = append(upcoming, gw)
+	}
+	return upcoming
+}
+
+// runDiff of the old,new snapshot paths as a report on stdout and json into jsonPath
+func runDiff(paths, jsonPath string) error {
+	parts := strings.Split(paths, ",")
+	if len(parts) != 2 {
+		return fmt.Errorf("-diff wants old,new snapshots, got %q", paths)
+	}
+	oldName, newName := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	oldRes, err := diff.Load(oldName)
+	if err != nil {
+		return err
+	}
+	newRes, err := diff.Load(newName)
+	if err != nil {
+		return err
+	}
+	report := diff.Compare(oldName, oldRes, newName, newRes)
+	if err := report.WriteText(os.Stdout); err != nil {
+		return err
+	}
+	file, err := os.Create(jsonPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return report.WriteJSON(file)
+}
+
+// runLive points of gameweek once, or every interval until interrupted,
+// with true scores of entryIDs including provisional bonus
+func runLive(ctx context.Context, genCli client.GenericClient, fplInfo *fpl.FPL, gameweek int, interval time.Duration, entryIDs []int) error {
+	fixturesInfo := fixtures.Fixtures{
+		Client:   genCli,
+		Registry: fplInfo.Registry,
+	}
+	fixturesInfo.Client.Endpoint = fixtures.Endpoint
+	liveInfo := live.Live{
+		Client:      genCli,
+		Registry:    fplInfo.Registry,
+		Gameweek:    gameweek,
+		Team2Player: fplInfo.Team2Player,
+		Fixtures:    &fixturesInfo,
+	}
+	liveInfo.Client.Endpoint = live.RawEndpoint
+
+	// picks are locked for the gameweek, fetch them once
+	entries := []entry.Entry{}
+	for _, id := range entryIDs {
+		entryInfo := entry.Entry{Client: genCli, ID: id}
+		entryInfo.Client.Endpoint = entry.RawEndpoint
+		if _, err := entryInfo.FetchPicks(ctx, gameweek); err != nil {
+			log.Printf("error FetchPicks() on entry %d: %+v\n", id, err)
+			continue
+		}
+		entries = append(entries, entryInfo)
+	}
+	onUpdate := func(l *live.Live) {
+		if totals := l.TeamTotals(); len(totals) > 0 {
+			log.Printf("live GW%d leading team: %+v\n", l.Gameweek, totals[0])
+		}
+		for i := range entries {
+			err := autosub.EntryToCsv(&entries[i], fplInfo.Registry, func(gw int) map[int]autosub.Appearance {
+				return autosub.FromLive(l, &fixturesInfo, fplInfo.Res.Players)
+			})
+			if err != nil {
+				log.Printf("error autosub.EntryToCsv() on entry %d: %+v\n", entries[i].ID, err)
+			}
+		}
+	}
+
+	if interval <= 0 {
+		if err := liveInfo.GetLiveToCsv(ctx); err != nil {
+			return err
+		}
+		onUpdate(&liveInfo)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+	err := liveInfo.Poll(ctx, interval, onUpdate)
+	if err == context.Canceled {
+		return nil
+	}
+	return err
 }
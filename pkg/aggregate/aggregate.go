@@ -0,0 +1,300 @@
+// Package aggregate provides per team, position or team×position metrics
+// over any numeric field of team.Player or element.History rows
+package aggregate
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// GroupBy of an Aggregator
+type GroupBy int
+
+// ByTeam, ByPosition or ByTeamPosition
+const (
+	ByTeam GroupBy = iota
+	ByPosition
+	ByTeamPosition
+)
+
+// Metric reduces Field of every row in a group, Filter drops samples before reducing
+type Metric struct {
+	Name    string
+	Field   string
+	Reducer Reducer
+	Filter  func(s Sample) bool
+}
+
+// Result of a Metric for a group, Team or Position is empty when not grouped by it
+type Result struct {
+	Team     string
+	Position string
+	Metric   string
+	Count    int
+	Value    float64
+}
+
+// Aggregator computes the metrics of Metrics, a Registry of the row type aggregated.
+// Team and Position of a row default to its TeamName (or Team) and RoleName fields.
+type Aggregator struct {
+	GroupBy  GroupBy
+	Metrics  *Registry
+	Team     func(row interface{}) string
+	Position func(row interface{}) string
+}
+
+// Registry of metrics by name, one per row type so that a metric of one type
+// never leaks into the aggregation of another
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]Metric
+}
+
+// NewRegistry of metrics, e.g. NewRegistry(Common()...) plus the metrics of a row type
+func NewRegistry(metrics ...Metric) (*Registry, error) {
+	r := &Registry{metrics: make(map[string]Metric)}
+	for _, m := range metrics {
+		if err := r.Register(m); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// MustNewRegistry or panic, for package level registries
+func MustNewRegistry(metrics ...Metric) *Registry {
+	r, err := NewRegistry(metrics...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Register m to be computed by name, without editing the packages using Aggregator
+func (r *Registry) Register(m Metric) error {
+	if m.Name == "" || m.Field == "" || m.Reducer == nil {
+		return fmt.Errorf("metric needs Name, Field and Reducer: %+v", m)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[m.Name]; ok {
+		return fmt.Errorf("metric %q already registered", m.Name)
+	}
+	r.metrics[m.Name] = m
+	return nil
+}
+
+// Lookup registered metric by name
+func (r *Registry) Lookup(name string) (Metric, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.metrics[name]
+	return m, ok
+}
+
+// Names of every registered metric, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compute metricNames of Metrics over rows, a slice of structs. When metricNames is empty,
+// every metric of Metrics whose Field exists in the rows is computed.
+// Results are ordered by team, position then metric name.
+func (a Aggregator) Compute(rows interface{}, metricNames ...string) ([]Result, error) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("rows must be a slice, got %v", v.Kind())
+	}
+	rowType := v.Type().Elem()
+	if rowType.Kind() == reflect.Ptr {
+		rowType = rowType.Elem()
+	}
+	if rowType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("rows must be a slice of structs, got %v", rowType)
+	}
+
+	if a.Metrics == nil {
+		return nil, fmt.Errorf("aggregator of %v rows without a metric registry", rowType)
+	}
+	selected := []Metric{}
+	if len(metricNames) == 0 {
+		for _, name := range a.Metrics.Names() {
+			m, _ := a.Metrics.Lookup(name)
+			if _, ok := rowType.FieldByName(m.Field); ok {
+				selected = append(selected, m)
+			}
+		}
+	}
+	for _, name := range metricNames {
+		m, ok := a.Metrics.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("metric %q not registered", name)
+		}
+		selected = append(selected, m)
+	}
+	type groupKey struct{ team, position string }
+	groups := make(map[groupKey]map[string][]Sample)
+	for i := 0; i < v.Len(); i++ {
+		row := reflect.Indirect(v.Index(i))
+		key := a.key(row)
+		if _, ok := groups[key]; !ok {
+			groups[key] = make(map[string][]Sample)
+		}
+		for _, m := range selected {
+			s, err := sample(row, m.Field)
+			if err != nil {
+				return nil, fmt.Errorf("metric %q: %v", m.Name, err)
+			}
+			if m.Filter == nil || m.Filter(s) {
+				groups[key][m.Name] = append(groups[key][m.Name], s)
+			}
+		}
+	}
+
+	results := []Result{}
+	for key, samplesPerMetric := range groups {
+		for _, m := range selected {
+			samples := samplesPerMetric[m.Name]
+			results = append(results, Result{
+				Team:     key.team,
+				Position: key.position,
+				Metric:   m.Name,
+				Count:    len(samples),
+				Value:    m.Reducer(samples),
+			})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Team != results[j].Team {
+			return results[i].Team < results[j].Team
+		}
+		if results[i].Position != results[j].Position {
+			return results[i].Position < results[j].Position
+		}
+		return results[i].Metric < results[j].Metric
+	})
+	return results, nil
+}
+
+// key of row according to GroupBy
+func (a Aggregator) key(row reflect.Value) (key struct{ team, position string }) {
+	if a.GroupBy == ByTeam || a.GroupBy == ByTeamPosition {
+		if a.Team != nil {
+			key.team = a.Team(row.Interface())
+		} else {
+			key.team = stringField(row, "TeamName", "Team")
+		}
+	}
+	if a.GroupBy == ByPosition || a.GroupBy == ByTeamPosition {
+		if a.Position != nil {
+			key.position = a.Position(row.Interface())
+		} else {
+			key.position = stringField(row, "RoleName")
+		}
+	}
+	return key
+}
+
+// sample field of row with its Minutes, NowCost (or Value) and ID (or PlayerID) fields
+func sample(row reflect.Value, field string) (Sample, error) {
+	f := row.FieldByName(field)
+	if !f.IsValid() {
+		return Sample{}, fmt.Errorf("no field %v in %v", field, row.Type())
+	}
+	value, err := number(f)
+	if err != nil {
+		return Sample{}, fmt.Errorf("field %v: %v", field, err)
+	}
+	s := Sample{Value: value}
+	if minutes := row.FieldByName("Minutes"); minutes.IsValid() {
+		s.Minutes, _ = number(minutes)
+	}
+	for _, costField := range []string{"NowCost", "Value"} {
+		if cost := row.FieldByName(costField); cost.IsValid() {
+			s.Cost, _ = number(cost)
+			break
+		}
+	}
+	for _, idField := range []string{"PlayerID", "ID"} {
+		if id := row.FieldByName(idField); id.IsValid() && id.Kind() == reflect.Int {
+			s.Player = int(id.Int())
+			break
+		}
+	}
+	return s, nil
+}
+
+// Field value of a numeric field of row, a struct or a pointer to struct
+func Field(row interface{}, field string) (float64, error) {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	if v.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("row must be a struct, got %v", v.Kind())
+	}
+	f := v.FieldByName(field)
+	if !f.IsValid() {
+		return 0, fmt.Errorf("no field %v in %v", field, v.Type())
+	}
+	return number(f)
+}
+
+// number from any int, uint, float or numeric string field
+func number(f reflect.Value) (float64, error) {
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(f.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(f.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return f.Float(), nil
+	case reflect.String:
+		if f.String() == "" {
+			return 0, nil
+		}
+		return strconv.ParseFloat(f.String(), 64)
+	}
+	return 0, fmt.Errorf("%v is not numeric", f.Type())
+}
+
+// stringField first found among names
+func stringField(row reflect.Value, names ...string) string {
+	for _, name := range names {
+		if f := row.FieldByName(name); f.IsValid() && f.Kind() == reflect.String {
+			return f.String()
+		}
+	}
+	return ""
+}
+
+// Row of a Result ready for csv.StructSlice
+type Row struct {
+	Team     string
+	Position string
+	Metric   string
+	Count    int
+	Value    float64
+}
+
+// Rows from results
+func Rows(results []Result) []Row {
+	rows := make([]Row, len(results))
+	for i, r := range results {
+		rows[i] = Row{
+			Team:     r.Team,
+			Position: r.Position,
+			Metric:   r.Metric,
+			Count:    r.Count,
+			Value:    r.Value,
+		}
+	}
+	return rows
+}
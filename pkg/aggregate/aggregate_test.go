@@ -0,0 +1,149 @@
+package aggregate_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/aggregate"
+)
+
+type row struct {
+	ID          int
+	TeamName    string
+	RoleName    string
+	TotalPoints int
+	Minutes     int
+	NowCost     int
+}
+
+var rows = []row{
+	{ID: 1, TeamName: "ARS", RoleName: "MID", TotalPoints: 2, Minutes: 90, NowCost: 50},
+	{ID: 2, TeamName: "ARS", RoleName: "FWD", TotalPoints: 4, Minutes: 45, NowCost: 70},
+	{ID: 3, TeamName: "CHE", RoleName: "MID", TotalPoints: 9, Minutes: 90, NowCost: 60},
+	// never played
+	{ID: 4, TeamName: "LIV", RoleName: "MID", NowCost: 40},
+}
+
+func TestCompute(t *testing.T) {
+	metrics := aggregate.MustNewRegistry(
+		aggregate.Metric{Name: "sum", Field: "TotalPoints", Reducer: aggregate.Sum},
+		aggregate.Metric{Name: "avg", Field: "TotalPoints", Reducer: aggregate.Mean},
+		aggregate.Metric{Name: "max", Field: "TotalPoints", Reducer: aggregate.Max},
+		aggregate.Metric{Name: "played_avg", Field: "TotalPoints", Reducer: aggregate.Mean, Filter: aggregate.Played},
+	)
+	tests := []struct {
+		name    string
+		groupBy aggregate.GroupBy
+		want    []aggregate.Result
+	}{
+		{
+			name:    "by team",
+			groupBy: aggregate.ByTeam,
+			want: []aggregate.Result{
+				{Team: "ARS", Metric: "avg", Count: 2, Value: 3},
+				{Team: "ARS", Metric: "max", Count: 2, Value: 4},
+				{Team: "ARS", Metric: "played_avg", Count: 2, Value: 3},
+				{Team: "ARS", Metric: "sum", Count: 2, Value: 6},
+				{Team: "CHE", Metric: "avg", Count: 1, Value: 9},
+				{Team: "CHE", Metric: "max", Count: 1, Value: 9},
+				{Team: "CHE", Metric: "played_avg", Count: 1, Value: 9},
+				{Team: "CHE", Metric: "sum", Count: 1, Value: 9},
+				{Team: "LIV", Metric: "avg", Count: 1},
+				{Team: "LIV", Metric: "max", Count: 1},
+				// every sample filtered out, an empty group
+				{Team: "LIV", Metric: "played_avg"},
+				{Team: "LIV", Metric: "sum", Count: 1},
+			},
+		},
+		{
+			name:    "by position",
+			groupBy: aggregate.ByPosition,
+			want: []aggregate.Result{
+				{Position: "FWD", Metric: "avg", Count: 1, Value: 4},
+				{Position: "FWD", Metric: "max", Count: 1, Value: 4},
+				{Position: "FWD", Metric: "played_avg", Count: 1, Value: 4},
+				{Position: "FWD", Metric: "sum", Count: 1, Value: 4},
+				{Position: "MID", Metric: "avg", Count: 3, Value: 11.0 / 3},
+				{Position: "MID", Metric: "max", Count: 3, Value: 9},
+				{Position: "MID", Metric: "played_avg", Count: 2, Value: 5.5},
+				{Position: "MID", Metric: "sum", Count: 3, Value: 11},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := aggregate.Aggregator{GroupBy: tt.groupBy, Metrics: metrics}.Compute(rows)
+			if err != nil {
+				t.Fatalf("Compute() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compute() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReducers(t *testing.T) {
+	samples := []aggregate.Sample{
+		{Value: 2, Minutes: 90, Cost: 50, Player: 1},
+		{Value: 6, Minutes: 90, Cost: 70, Player: 1},
+		{Value: 1, Minutes: 0, Cost: 40, Player: 2},
+	}
+	tests := []struct {
+		name    string
+		reducer aggregate.Reducer
+		want    float64
+	}{
+		{name: "sum", reducer: aggregate.Sum, want: 9},
+		{name: "mean", reducer: aggregate.Mean, want: 3},
+		{name: "max", reducer: aggregate.Max, want: 6},
+		{name: "median", reducer: aggregate.Median, want: 2},
+		{name: "per 90", reducer: aggregate.Per90, want: 4.5},
+		// player 1 at an average 6.0m and player 2 at 4.0m
+		{name: "per million", reducer: aggregate.PerMillion, want: 0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reducer(samples); got != tt.want {
+				t.Errorf("%v() = %v, want %v", tt.name, got, tt.want)
+			}
+			if got := tt.reducer(nil); got != 0 {
+				t.Errorf("%v() of an empty group = %v, want 0", tt.name, got)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	players := aggregate.MustNewRegistry(aggregate.Common()...)
+	history := aggregate.MustNewRegistry(aggregate.Common()...)
+	cost := aggregate.Metric{Name: "cost", Field: "NowCost", Reducer: aggregate.Sum}
+	if err := players.Register(cost); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := players.Register(cost); err == nil {
+		t.Errorf("Register() twice, want an error")
+	}
+	if err := players.Register(aggregate.Metric{Name: "no field", Reducer: aggregate.Sum}); err == nil {
+		t.Errorf("Register() without Field, want an error")
+	}
+	if _, ok := history.Lookup("cost"); ok {
+		t.Errorf("metric of one registry found in another")
+	}
+
+	results, err := aggregate.Aggregator{Metrics: history}.Compute(rows)
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+	for _, r := range results {
+		if r.Metric == "cost" {
+			t.Errorf("Compute() with another registry's metric %+v", r)
+		}
+	}
+	if _, err := (aggregate.Aggregator{Metrics: history}).Compute(rows, "cost"); err == nil {
+		t.Errorf("Compute() of a metric not in Metrics, want an error")
+	}
+	if _, err := (aggregate.Aggregator{}).Compute(rows); err == nil {
+		t.Errorf("Compute() without Metrics, want an error")
+	}
+}
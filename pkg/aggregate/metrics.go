@@ -0,0 +1,17 @@
+package aggregate
+
+// Played drops samples of rows without any minutes
+func Played(s Sample) bool {
+	return s.Minutes > 0
+}
+
+// Common metrics over fields shared by team.Player and element.History
+func Common() []Metric {
+	return []Metric{
+		{Name: "total_points", Field: "TotalPoints", Reducer: Sum},
+		{Name: "minutes", Field: "Minutes", Reducer: Sum},
+		{Name: "median_points", Field: "TotalPoints", Reducer: Median, Filter: Played},
+		{Name: "points_per_90", Field: "TotalPoints", Reducer: Per90},
+		{Name: "points_per_million", Field: "TotalPoints", Reducer: PerMillion},
+	}
+}
@@ -0,0 +1,102 @@
+package aggregate
+
+import "sort"
+
+// Sample of a field value with the minutes and cost (in 0.1m, as the api) of its row,
+// Player its player ID, 0 when the row has none
+type Sample struct {
+	Value   float64
+	Minutes float64
+	Cost    float64
+	Player  int
+}
+
+// Reducer folds the samples of a group into a single number
+type Reducer func(samples []Sample) float64
+
+// Sum of values
+func Sum(samples []Sample) (total float64) {
+	for _, s := range samples {
+		total += s.Value
+	}
+	return total
+}
+
+// Mean of values
+func Mean(samples []Sample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	return Sum(samples) / float64(len(samples))
+}
+
+// Max of values, 0 without any
+func Max(samples []Sample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	max := samples[0].Value
+	for _, s := range samples[1:] {
+		if s.Value > max {
+			max = s.Value
+		}
+	}
+	return max
+}
+
+// Median of values
+func Median(samples []Sample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = s.Value
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// Per90 minutes played, summed over the group
+func Per90(samples []Sample) float64 {
+	minutes := 0.0
+	for _, s := range samples {
+		minutes += s.Minutes
+	}
+	if minutes == 0 {
+		return 0
+	}
+	return Sum(samples) / minutes * 90
+}
+
+// PerMillion of cost, summed over the players of the group. The cost of a player with
+// many rows, one per match of element.History, is its average over them.
+func PerMillion(samples []Sample) float64 {
+	type total struct {
+		cost  float64
+		count int
+	}
+	player2Cost := make(map[int]total)
+	cost := 0.0
+	for _, s := range samples {
+		if s.Player == 0 {
+			cost += s.Cost
+			continue
+		}
+		t := player2Cost[s.Player]
+		t.cost += s.Cost
+		t.count++
+		player2Cost[s.Player] = t
+	}
+	for _, t := range player2Cost {
+		cost += t.cost / float64(t.count)
+	}
+	if cost == 0 {
+		return 0
+	}
+	return Sum(samples) / (cost / 10)
+}
@@ -0,0 +1,53 @@
+package autosub
+
+import (
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/live"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// FromHistory appearances of gameweek gw, doubles summed. A player is Done as in
+// FromLive, so one whose match is still to come in gw has no history row yet but
+// is not subbed off.
+func FromHistory(gw int, x *fixtures.Fixtures, players []team.Player, id2History map[int][]element.History) map[int]Appearance {
+	id2Appearance := make(map[int]Appearance, len(players))
+	for _, p := range players {
+		a := Appearance{RoleID: p.RoleID, Done: done(x, p.TeamID, gw)}
+		for _, h := range id2History[p.ID] {
+			if h.Round == gw {
+				a.Minutes += h.Minutes
+				a.Points += h.TotalPoints
+			}
+		}
+		id2Appearance[p.ID] = a
+	}
+	return id2Appearance
+}
+
+// FromLive appearances of l.Gameweek with provisional bonus, a player is Done once
+// every fixture of his team in x is finished, or right away when his team blanks
+func FromLive(l *live.Live, x *fixtures.Fixtures, players []team.Player) map[int]Appearance {
+	id2Appearance := make(map[int]Appearance, len(players))
+	for _, p := range players {
+		e := l.ID2Element[p.ID]
+		a := Appearance{
+			RoleID:  p.RoleID,
+			Minutes: e.Stats.Minutes,
+			Points:  l.Points(p.ID),
+			Done:    done(x, p.TeamID, l.Gameweek),
+		}
+		id2Appearance[p.ID] = a
+	}
+	return id2Appearance
+}
+
+// done once every fixture of teamID in gw is finished, right away when it blanks
+func done(x *fixtures.Fixtures, teamID, gw int) bool {
+	for _, f := range x.Team2Gw2Fixture[teamID][gw] {
+		if !f.Finished && !f.FinishedProvisional {
+			return false
+		}
+	}
+	return true
+}
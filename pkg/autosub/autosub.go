@@ -0,0 +1,175 @@
+// Package autosub provides the official automatic substitution rules and a manager's true gameweek score
+package autosub
+
+import (
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+)
+
+// element_type of each position
+const (
+	GKP = 1
+	DEF = 2
+	MID = 3
+	FWD = 4
+)
+
+// minimum starters per element_type in a valid formation, besides exactly 1 GKP
+var minStarters = map[int]int{DEF: 3, MID: 2, FWD: 1}
+
+// Appearance of a player in the gameweek. Done once every fixture of his team is over,
+// a player who is not Done is never substituted.
+type Appearance struct {
+	RoleID  int
+	Minutes int
+	Points  int
+	Done    bool
+}
+
+// Sub of bench ElementIn for starter ElementOut
+type Sub struct {
+	ElementOut int
+	ElementIn  int
+}
+
+// Result of a manager's gameweek after automatic substitutions and chips
+type Result struct {
+	Points        int
+	GrossPoints   int
+	TransfersCost int
+	BenchPoints   int
+	Captain       int
+	Subs          []Sub
+	Multipliers   map[int]int
+	Provisional   bool
+}
+
+// Score picks with id2Appearance, applying in order: bench boost, automatic
+// substitutions by bench order keeping a valid formation, then vice-captain promotion
+func Score(picks entry.PicksResponse, id2Appearance map[int]Appearance) Result {
+	ordered := make([]entry.Pick, len(picks.Picks))
+	for _, pick := range picks.Picks {
+		if pick.Position >= 1 && pick.Position <= len(ordered) {
+			ordered[pick.Position-1] = pick
+		}
+	}
+	res := Result{
+		Multipliers:   make(map[int]int, len(ordered)),
+		TransfersCost: picks.EntryHistory.EventTransfersCost,
+	}
+	benchBoost := picks.ActiveChip == entry.BenchBoost
+
+	starting := make([]int, 0, entry.StartingPositions)
+	bench := []int{}
+	for i, pick := range ordered {
+		if i < entry.StartingPositions || benchBoost {
+			starting = append(starting, pick.Element)
+		} else {
+			bench = append(bench, pick.Element)
+		}
+		if a := id2Appearance[pick.Element]; !a.Done {
+			res.Provisional = true
+		}
+	}
+	if !benchBoost {
+		res.Subs = substitute(starting, bench, id2Appearance)
+		for _, sub := range res.Subs {
+			for i, id := range starting {
+				if id == sub.ElementOut {
+					starting[i] = sub.ElementIn
+				}
+			}
+		}
+	}
+
+	for _, id := range starting {
+		res.Multipliers[id] = 1
+	}
+	res.Captain = captain(ordered, res.Multipliers, id2Appearance)
+	if res.Captain > 0 {
+		res.Multipliers[res.Captain] = 2
+		if picks.ActiveChip == entry.TripleCaptain {
+			res.Multipliers[res.Captain] = 3
+		}
+	}
+
+	for _, pick := range ordered {
+		points := id2Appearance[pick.Element].Points
+		if multiplier, ok := res.Multipliers[pick.Element]; ok {
+			res.GrossPoints += points * multiplier
+		} else {
+			res.BenchPoints += points
+		}
+	}
+	res.Points = res.GrossPoints - res.TransfersCost
+	return res
+}
+
+// substitute starters who did not play with the first bench player who did,
+// goalkeeper for goalkeeper, outfielders only when the formation stays valid
+func substitute(starting, bench []int, id2Appearance map[int]Appearance) []Sub {
+	subs := []Sub{}
+	used := make(map[int]bool)
+	xi := append([]int{}, starting...)
+	for i, out := range starting {
+		a := id2Appearance[out]
+		if a.Minutes > 0 || !a.Done {
+			continue
+		}
+		for _, in := range bench {
+			b := id2Appearance[in]
+			if used[in] || b.Minutes <= 0 {
+				continue
+			}
+			if (a.RoleID == GKP) != (b.RoleID == GKP) {
+				continue
+			}
+			xi[i] = in
+			if !validFormation(xi, id2Appearance) {
+				xi[i] = out
+				continue
+			}
+			used[in] = true
+			subs = append(subs, Sub{ElementOut: out, ElementIn: in})
+			break
+		}
+	}
+	return subs
+}
+
+// validFormation with exactly 1 GKP and minStarters of each outfield position
+func validFormation(xi []int, id2Appearance map[int]Appearance) bool {
+	count := make(map[int]int)
+	for _, id := range xi {
+		count[id2Appearance[id].RoleID]++
+	}
+	if count[GKP] != 1 {
+		return false
+	}
+	for roleID, min := range minStarters {
+		if count[roleID] < min {
+			return false
+		}
+	}
+	return true
+}
+
+// captain who played, the vice captain when the captain did not, 0 when neither did.
+// Only players in the final xi are eligible.
+func captain(picks []entry.Pick, xi map[int]int, id2Appearance map[int]Appearance) int {
+	captainID, viceID := 0, 0
+	for _, pick := range picks {
+		if pick.IsCaptain {
+			captainID = pick.Element
+		}
+		if pick.IsViceCaptain {
+			viceID = pick.Element
+		}
+	}
+	for _, id := range []int{captainID, viceID} {
+		a := id2Appearance[id]
+		if _, ok := xi[id]; ok && id > 0 && (a.Minutes > 0 || !a.Done) {
+			return id
+		}
+	}
+	return 0
+}
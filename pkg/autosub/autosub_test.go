@@ -0,0 +1,218 @@
+package autosub
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// testPicks of a 3-5-2 with captain 9 and vice captain 10,
+// bench ordered goalkeeper 12, then 13 MID, 14 FWD, 15 DEF
+func testPicks(activeChip string) entry.PicksResponse {
+	picks := entry.PicksResponse{ActiveChip: activeChip}
+	for id := 1; id <= 15; id++ {
+		picks.Picks = append(picks.Picks, entry.Pick{
+			Element:       id,
+			Position:      id,
+			IsCaptain:     id == 9,
+			IsViceCaptain: id == 10,
+		})
+	}
+	return picks
+}
+
+// testAppearances of testPicks, everyone done with 90 minutes and 2 points
+// but the ids in out who did not play
+func testAppearances(out ...int) map[int]Appearance {
+	roles := []int{GKP, DEF, DEF, DEF, MID, MID, MID, MID, MID, FWD, FWD, GKP, MID, FWD, DEF}
+	id2Appearance := make(map[int]Appearance, len(roles))
+	for i, roleID := range roles {
+		id2Appearance[i+1] = Appearance{RoleID: roleID, Minutes: 90, Points: 2, Done: true}
+	}
+	for _, id := range out {
+		a := id2Appearance[id]
+		a.Minutes, a.Points = 0, 0
+		id2Appearance[id] = a
+	}
+	return id2Appearance
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name            string
+		activeChip      string
+		id2Appearance   map[int]Appearance
+		wantSubs        []Sub
+		wantCaptain     int
+		wantPoints      int
+		wantProvisional bool
+	}{
+		{
+			name:          "everyone played",
+			id2Appearance: testAppearances(),
+			wantCaptain:   9,
+			wantPoints:    24,
+		},
+		{
+			name:          "keeper for keeper",
+			id2Appearance: testAppearances(1),
+			wantSubs:      []Sub{{ElementOut: 1, ElementIn: 12}},
+			wantCaptain:   9,
+			wantPoints:    24,
+		},
+		{
+			name:          "keeper never replaced by an outfielder",
+			id2Appearance: testAppearances(1, 12),
+			wantCaptain:   9,
+			wantPoints:    22,
+		},
+		{
+			name:          "sub breaking the formation skipped",
+			id2Appearance: testAppearances(2),
+			wantSubs:      []Sub{{ElementOut: 2, ElementIn: 15}},
+			wantCaptain:   9,
+			wantPoints:    24,
+		},
+		{
+			name:          "first bench player in priority order",
+			id2Appearance: testAppearances(5),
+			wantSubs:      []Sub{{ElementOut: 5, ElementIn: 13}},
+			wantCaptain:   9,
+			wantPoints:    24,
+		},
+		{
+			name:          "bench player who did not play skipped",
+			id2Appearance: testAppearances(5, 13),
+			wantSubs:      []Sub{{ElementOut: 5, ElementIn: 14}},
+			wantCaptain:   9,
+			wantPoints:    24,
+		},
+		{
+			name:          "starters replaced in order by the bench in order",
+			id2Appearance: testAppearances(6, 5),
+			wantSubs:      []Sub{{ElementOut: 5, ElementIn: 13}, {ElementOut: 6, ElementIn: 14}},
+			wantCaptain:   9,
+			wantPoints:    24,
+		},
+		{
+			name: "starter still to play kept",
+			id2Appearance: func() map[int]Appearance {
+				id2Appearance := testAppearances(5)
+				a := id2Appearance[5]
+				a.Done = false
+				id2Appearance[5] = a
+				return id2Appearance
+			}(),
+			wantCaptain:     9,
+			wantPoints:      22,
+			wantProvisional: true,
+		},
+		{
+			name:          "vice captain promoted",
+			id2Appearance: testAppearances(9),
+			wantSubs:      []Sub{{ElementOut: 9, ElementIn: 13}},
+			wantCaptain:   10,
+			wantPoints:    24,
+		},
+		{
+			name:          "vice captain promoted to triple captain",
+			activeChip:    entry.TripleCaptain,
+			id2Appearance: testAppearances(9),
+			wantSubs:      []Sub{{ElementOut: 9, ElementIn: 13}},
+			wantCaptain:   10,
+			wantPoints:    26,
+		},
+		{
+			name:          "neither captain played",
+			id2Appearance: testAppearances(9, 10),
+			wantSubs:      []Sub{{ElementOut: 9, ElementIn: 13}, {ElementOut: 10, ElementIn: 14}},
+			wantPoints:    22,
+		},
+		{
+			name:          "bench boost without subs",
+			activeChip:    entry.BenchBoost,
+			id2Appearance: testAppearances(5),
+			wantCaptain:   9,
+			wantPoints:    30,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Score(testPicks(tt.activeChip), tt.id2Appearance)
+			if (len(res.Subs) > 0 || len(tt.wantSubs) > 0) && !reflect.DeepEqual(res.Subs, tt.wantSubs) {
+				t.Errorf("Subs = %+v, want %+v", res.Subs, tt.wantSubs)
+			}
+			if res.Captain != tt.wantCaptain {
+				t.Errorf("Captain = %d, want %d", res.Captain, tt.wantCaptain)
+			}
+			if res.Points != tt.wantPoints {
+				t.Errorf("Points = %d, want %d", res.Points, tt.wantPoints)
+			}
+			if res.Provisional != tt.wantProvisional {
+				t.Errorf("Provisional = %v, want %v", res.Provisional, tt.wantProvisional)
+			}
+		})
+	}
+}
+
+func TestSubstituteKeepsFormation(t *testing.T) {
+	id2Appearance := testAppearances(2, 3)
+	starting := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	// only a midfielder and a forward on the bench: no defender can come in
+	subs := substitute(starting, []int{12, 13, 14}, id2Appearance)
+	if len(subs) != 0 {
+		t.Errorf("substitute() = %+v, want none below 3 defenders", subs)
+	}
+	subs = substitute(starting, []int{12, 13, 15, 14}, id2Appearance)
+	if want := []Sub{{ElementOut: 2, ElementIn: 15}}; !reflect.DeepEqual(subs, want) {
+		t.Errorf("substitute() = %+v, want %+v", subs, want)
+	}
+}
+
+func TestFromHistory(t *testing.T) {
+	roles := []int{GKP, DEF, DEF, DEF, MID, MID, MID, MID, MID, FWD, FWD, GKP, MID, FWD, DEF}
+	players := []team.Player{}
+	id2History := make(map[int][]element.History)
+	for i, roleID := range roles {
+		p := team.Player{ID: i + 1, RoleID: roleID, TeamID: 1}
+		if p.ID == 5 {
+			// no history row yet for his match in gameweek 3
+			p.TeamID = 2
+		} else {
+			id2History[p.ID] = []element.History{{Round: 3, Minutes: 90, TotalPoints: 2}}
+		}
+		players = append(players, p)
+	}
+	tests := []struct {
+		name            string
+		finished        bool
+		wantSubs        []Sub
+		wantPoints      int
+		wantProvisional bool
+	}{
+		{name: "starter still to play kept", wantPoints: 22, wantProvisional: true},
+		{name: "starter who did not play subbed", finished: true, wantSubs: []Sub{{ElementOut: 5, ElementIn: 13}}, wantPoints: 24},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := &fixtures.Fixtures{Team2Gw2Fixture: map[int]map[int][]fixtures.Fixture{
+				1: {3: {{Event: 3, TeamH: 1, TeamA: 3, Finished: true}}},
+				2: {3: {{Event: 3, TeamH: 2, TeamA: 4, Finished: tt.finished}}},
+			}}
+			res := Score(testPicks(""), FromHistory(3, x, players, id2History))
+			if (len(res.Subs) > 0 || len(tt.wantSubs) > 0) && !reflect.DeepEqual(res.Subs, tt.wantSubs) {
+				t.Errorf("Subs = %+v, want %+v", res.Subs, tt.wantSubs)
+			}
+			if res.Points != tt.wantPoints {
+				t.Errorf("Points = %d, want %d", res.Points, tt.wantPoints)
+			}
+			if res.Provisional != tt.wantProvisional {
+				t.Errorf("Provisional = %v, want %v", res.Provisional, tt.wantProvisional)
+			}
+		})
+	}
+}
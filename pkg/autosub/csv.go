@@ -0,0 +1,50 @@
+package autosub
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+)
+
+// Row of an entry's Result in a gameweek for csv.StructSlice
+type Row struct {
+	Entry         int
+	Gameweek      int
+	Points        int
+	GrossPoints   int
+	TransfersCost int
+	BenchPoints   int
+	Captain       string
+	Subs          string
+	ActiveChip    string
+	Provisional   bool
+}
+
+// EntryToCsv true scores of every fetched gameweek of e, appearances by gameweek
+func EntryToCsv(e *entry.Entry, r *registry.Registry, gw2Appearances func(gw int) map[int]Appearance) error {
+	rows := []Row{}
+	for _, gw := range e.Gameweeks() {
+		picks := e.Gw2Picks[gw]
+		res := Score(picks, gw2Appearances(gw))
+		subs := []string{}
+		for _, sub := range res.Subs {
+			subs = append(subs, fmt.Sprintf("%v>%v", r.PlayerName(sub.ElementOut), r.PlayerName(sub.ElementIn)))
+		}
+		rows = append(rows, Row{
+			Entry:         e.ID,
+			Gameweek:      gw,
+			Points:        res.Points,
+			GrossPoints:   res.GrossPoints,
+			TransfersCost: res.TransfersCost,
+			BenchPoints:   res.BenchPoints,
+			Captain:       r.PlayerName(res.Captain),
+			Subs:          strings.Join(subs, " "),
+			ActiveChip:    picks.ActiveChip,
+			Provisional:   res.Provisional,
+		})
+	}
+	return csv.StructSlice(rows, fmt.Sprintf("fpl-entries/%d-autosub", e.ID))
+}
@@ -0,0 +1,219 @@
+// Package backtest provides a gameweek by gameweek replay of a season from element-summary
+// history, scoring pluggable projection models fitted on earlier gameweeks' points only
+package backtest
+
+import (
+	"math"
+	"sort"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// DefaultTopN players per position of the hit rate of a zero Backtester
+const DefaultTopN = 10
+
+// AllPositions of a Result over every position
+const AllPositions = "ALL"
+
+// Projector of expected points, Fit before each gameweek with the history
+// of earlier gameweeks only
+type Projector interface {
+	Name() string
+	Fit(asOf int)
+	Project(id, gw int) float64
+}
+
+// Backtester replays Gameweeks, projecting every player who has a fixture in it.
+// Players keep their current TeamID, so projectors on team strengths see today's clubs.
+type Backtester struct {
+	Players    []team.Player
+	ID2History map[int][]element.History
+	Registry   *registry.Registry
+	Gameweeks  []int
+	TopN       int
+}
+
+// Result of a Projector for a position over the replayed gameweeks. Spearman and
+// TopNHitRate are averaged over gameweeks, TopNHitRate being the share of the TopN
+// projected players who end in the actual TopN.
+type Result struct {
+	Projector   string
+	Position    string
+	Gameweeks   int
+	Samples     int
+	MAE         float64
+	RMSE        float64
+	Spearman    float64
+	TopNHitRate float64
+}
+
+// sample of a player's projected and actual points in a gameweek
+type sample struct {
+	id        int
+	projected float64
+	actual    float64
+}
+
+// Run every projector over Gameweeks, results by projector then position with AllPositions first
+func (b Backtester) Run(projectors ...Projector) []Result {
+	topN := b.TopN
+	if topN == 0 {
+		topN = DefaultTopN
+	}
+	results := []Result{}
+	for _, p := range projectors {
+		// samples per position and gameweek
+		pos2Gw2Samples := make(map[string]map[int][]sample)
+		for _, gw := range b.Gameweeks {
+			p.Fit(gw)
+			for _, player := range b.Players {
+				if !played(b.ID2History[player.ID], gw) {
+					continue
+				}
+				s := sample{
+					id:        player.ID,
+					projected: p.Project(player.ID, gw),
+					actual:    float64(entry.GameweekPoints(b.ID2History[player.ID], gw)),
+				}
+				for _, pos := range []string{AllPositions, b.Registry.PositionName(player.RoleID)} {
+					if _, ok := pos2Gw2Samples[pos]; !ok {
+						pos2Gw2Samples[pos] = make(map[int][]sample)
+					}
+					pos2Gw2Samples[pos][gw] = append(pos2Gw2Samples[pos][gw], s)
+				}
+			}
+		}
+
+		positions := make([]string, 0, len(pos2Gw2Samples))
+		for pos := range pos2Gw2Samples {
+			positions = append(positions, pos)
+		}
+		sort.Slice(positions, func(i, j int) bool {
+			if (positions[i] == AllPositions) != (positions[j] == AllPositions) {
+				return positions[i] == AllPositions
+			}
+			return positions[i] < positions[j]
+		})
+		for _, pos := range positions {
+			results = append(results, evaluate(p.Name(), pos, pos2Gw2Samples[pos], topN))
+		}
+	}
+	return results
+}
+
+// played when historyList has a fixture in gw
+func played(historyList []element.History, gw int) bool {
+	for _, h := range historyList {
+		if h.Round == gw {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate samples of a position per gameweek
+func evaluate(projector, position string, gw2Samples map[int][]sample, topN int) Result {
+	res := Result{Projector: projector, Position: position}
+	absErr, sqErr := 0.0, 0.0
+	for _, samples := range gw2Samples {
+		res.Gameweeks++
+		for _, s := range samples {
+			res.Samples++
+			absErr += math.Abs(s.projected - s.actual)
+			sqErr += (s.projected - s.actual) * (s.projected - s.actual)
+		}
+		res.Spearman += spearman(samples)
+		res.TopNHitRate += hitRate(samples, topN)
+	}
+	if res.Samples > 0 {
+		res.MAE = absErr / float64(res.Samples)
+		res.RMSE = math.Sqrt(sqErr / float64(res.Samples))
+	}
+	if res.Gameweeks > 0 {
+		res.Spearman /= float64(res.Gameweeks)
+		res.TopNHitRate /= float64(res.Gameweeks)
+	}
+	return res
+}
+
+// spearman rank correlation of projected and actual points, ties sharing their average rank
+func spearman(samples []sample) float64 {
+	projected := ranks(samples, func(s sample) float64 { return s.projected })
+	actual := ranks(samples, func(s sample) float64 { return s.actual })
+	return pearson(projected, actual)
+}
+
+// ranks of samples by value, 1 for the lowest
+func ranks(samples []sample, value func(s sample) float64) []float64 {
+	idx := make([]int, len(samples))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return value(samples[idx[i]]) < value(samples[idx[j]]) })
+	res := make([]float64, len(samples))
+	for i := 0; i < len(idx); {
+		j := i
+		for j < len(idx) && value(samples[idx[j]]) == value(samples[idx[i]]) {
+			j++
+		}
+		avg := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			res[idx[k]] = avg
+		}
+		i = j
+	}
+	return res
+}
+
+// pearson correlation of x and y, 0 when either is constant
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	if n == 0 {
+		return 0
+	}
+	meanX, meanY := 0.0, 0.0
+	for i := range x {
+		meanX += x[i] / n
+		meanY += y[i] / n
+	}
+	cov, varX, varY := 0.0, 0.0, 0.0
+	for i := range x {
+		cov += (x[i] - meanX) * (y[i] - meanY)
+		varX += (x[i] - meanX) * (x[i] - meanX)
+		varY += (y[i] - meanY) * (y[i] - meanY)
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// hitRate of the topN projected samples among the topN actual ones
+func hitRate(samples []sample, topN int) float64 {
+	if topN > len(samples) {
+		topN = len(samples)
+	}
+	if topN == 0 {
+		return 0
+	}
+	top := func(value func(s sample) float64) map[int]bool {
+		sorted := append([]sample{}, samples...)
+		sort.SliceStable(sorted, func(i, j int) bool { return value(sorted[i]) > value(sorted[j]) })
+		ids := make(map[int]bool, topN)
+		for _, s := range sorted[:topN] {
+			ids[s.id] = true
+		}
+		return ids
+	}
+	projected := top(func(s sample) float64 { return s.projected })
+	hits := 0
+	for id := range top(func(s sample) float64 { return s.actual }) {
+		if projected[id] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(topN)
+}
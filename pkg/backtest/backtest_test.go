@@ -0,0 +1,107 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// samples of ids 1, 2, ... with projected and actual points
+func samples(projected, actual []float64) []sample {
+	s := make([]sample, len(projected))
+	for i := range projected {
+		s[i] = sample{id: i + 1, projected: projected[i], actual: actual[i]}
+	}
+	return s
+}
+
+func TestSpearman(t *testing.T) {
+	tests := []struct {
+		name              string
+		projected, actual []float64
+		want              float64
+	}{
+		{name: "same order", projected: []float64{1, 2, 3, 4}, actual: []float64{2, 5, 6, 9}, want: 1},
+		{name: "reversed", projected: []float64{1, 2, 3, 4}, actual: []float64{9, 6, 5, 2}, want: -1},
+		// ranks 1, 2.5, 2.5, 4 against 1, 2, 3, 4
+		{name: "tied projections", projected: []float64{1, 2, 2, 3}, actual: []float64{1, 2, 3, 4}, want: 3 / math.Sqrt(10)},
+		// ranks 1, 2, 3 against 1, 3, 2
+		{name: "one swap", projected: []float64{2, 4, 6}, actual: []float64{2, 6, 3}, want: 0.5},
+		{name: "constant projections", projected: []float64{3, 3, 3}, actual: []float64{1, 2, 3}, want: 0},
+		{name: "one player", projected: []float64{3}, actual: []float64{1}, want: 0},
+		{name: "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spearman(samples(tt.projected, tt.actual)); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("spearman() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHitRate(t *testing.T) {
+	tests := []struct {
+		name              string
+		projected, actual []float64
+		topN              int
+		want              float64
+	}{
+		// projected top 4, 3 against actual top 4, 1
+		{name: "half", projected: []float64{5, 1, 6, 7}, actual: []float64{8, 1, 2, 9}, topN: 2, want: 0.5},
+		{name: "all", projected: []float64{5, 1, 6, 7}, actual: []float64{1, 0, 2, 9}, topN: 3, want: 1},
+		// a tie keeps the earlier sample: projected top 1, 2 against actual top 3, 2
+		{name: "tie", projected: []float64{5, 5, 5}, actual: []float64{1, 2, 3}, topN: 2, want: 0.5},
+		{name: "fewer players than N", projected: []float64{1, 2}, actual: []float64{2, 1}, topN: 10, want: 1},
+		{name: "none", topN: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hitRate(samples(tt.projected, tt.actual), tt.topN); got != tt.want {
+				t.Errorf("hitRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fixedProjector of id2Gw2Points
+type fixedProjector map[int]map[int]float64
+
+func (p fixedProjector) Name() string               { return "fixed" }
+func (p fixedProjector) Fit(asOf int)               {}
+func (p fixedProjector) Project(id, gw int) float64 { return p[id][gw] }
+
+func TestRun(t *testing.T) {
+	b := Backtester{
+		Players: []team.Player{{ID: 1, RoleID: 1}, {ID: 2, RoleID: 2}, {ID: 3, RoleID: 2}},
+		ID2History: map[int][]element.History{
+			1: {{Round: 1, TotalPoints: 2}},
+			2: {{Round: 1, TotalPoints: 6}, {Round: 2, TotalPoints: 1}},
+			3: {{Round: 1, TotalPoints: 3}},
+		},
+		Gameweeks: []int{1, 2},
+		TopN:      2,
+	}
+	p := fixedProjector{1: {1: 2}, 2: {1: 4, 2: 1}, 3: {1: 6}}
+
+	// GW1 errors 0, 2 and 3, GW2 of one player projected right
+	want := []Result{
+		{Projector: "fixed", Position: AllPositions, Gameweeks: 2, Samples: 4, MAE: 5.0 / 4, RMSE: math.Sqrt(13.0 / 4), Spearman: 0.5 / 2, TopNHitRate: 1},
+		{Projector: "fixed", Position: "DEF", Gameweeks: 2, Samples: 3, MAE: 5.0 / 3, RMSE: math.Sqrt(13.0 / 3), Spearman: -1.0 / 2, TopNHitRate: 1},
+		{Projector: "fixed", Position: "GKP", Gameweeks: 1, Samples: 1, TopNHitRate: 1},
+	}
+	got := b.Run(p)
+	if len(got) != len(want) {
+		t.Fatalf("Run() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Projector != w.Projector || g.Position != w.Position || g.Gameweeks != w.Gameweeks || g.Samples != w.Samples ||
+			math.Abs(g.MAE-w.MAE) > 1e-9 || math.Abs(g.RMSE-w.RMSE) > 1e-9 ||
+			math.Abs(g.Spearman-w.Spearman) > 1e-9 || math.Abs(g.TopNHitRate-w.TopNHitRate) > 1e-9 {
+			t.Errorf("Run()[%d] = %+v, want %+v", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package backtest
+
+import (
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+)
+
+// Row of a Result for csv.StructSlice
+type Row struct {
+	Projector   string
+	Position    string
+	Gameweeks   int
+	Samples     int
+	MAE         float64
+	RMSE        float64
+	Spearman    float64
+	TopNHitRate float64
+}
+
+// Rows of results
+func Rows(results []Result) []Row {
+	rows := make([]Row, len(results))
+	for i, r := range results {
+		rows[i] = Row{
+			Projector:   r.Projector,
+			Position:    r.Position,
+			Gameweeks:   r.Gameweeks,
+			Samples:     r.Samples,
+			MAE:         r.MAE,
+			RMSE:        r.RMSE,
+			Spearman:    r.Spearman,
+			TopNHitRate: r.TopNHitRate,
+		}
+	}
+	return rows
+}
+
+// ToCsv of results
+func ToCsv(results []Result) error {
+	return csv.StructSlice(Rows(results), "fpl-backtest")
+}
@@ -0,0 +1,80 @@
+package backtest
+
+import (
+	"fmt"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/projection"
+)
+
+// ModelProjector adapts a projection.Model, refitted with AsOf on each gameweek
+type ModelProjector struct {
+	Model *projection.Model
+}
+
+// Name of the projection model
+func (p ModelProjector) Name() string {
+	return "model"
+}
+
+// Fit the model on history before asOf
+func (p ModelProjector) Fit(asOf int) {
+	p.Model.AsOf = asOf
+	p.Model.Fit()
+}
+
+// Project with the model
+func (p ModelProjector) Project(id, gw int) float64 {
+	return p.Model.Project(id, gw)
+}
+
+// meanProjector of the mean points of the last matches before asOf, every match when 0,
+// times the fixtures in the projected gameweek
+type meanProjector struct {
+	name       string
+	id2History map[int][]element.History
+	matches    int
+	asOf       int
+}
+
+// NewFormProjector of the mean points over the last matches, a baseline like the api's form
+func NewFormProjector(id2History map[int][]element.History, matches int) Projector {
+	return &meanProjector{name: fmt.Sprintf("form-%d", matches), id2History: id2History, matches: matches}
+}
+
+// NewPointsPerGameProjector of the season's mean points before each gameweek
+func NewPointsPerGameProjector(id2History map[int][]element.History) Projector {
+	return &meanProjector{name: "points-per-game", id2History: id2History}
+}
+
+func (p *meanProjector) Name() string {
+	return p.name
+}
+
+func (p *meanProjector) Fit(asOf int) {
+	p.asOf = asOf
+}
+
+func (p *meanProjector) Project(id, gw int) float64 {
+	past := []element.History{}
+	fixtures := 0
+	for _, h := range p.id2History[id] {
+		switch {
+		case h.Round == gw:
+			fixtures++
+		case h.Round < p.asOf:
+			past = append(past, h)
+		}
+	}
+	if p.matches > 0 && len(past) > p.matches {
+		past = past[len(past)-p.matches:]
+	}
+	if len(past) == 0 {
+		return 0
+	}
+	points := 0
+	for _, h := range past {
+		points += h.TotalPoints
+	}
+	return float64(points) / float64(len(past)) * float64(fixtures)
+}
@@ -0,0 +1,61 @@
+// Package bonus provides the provisional 3/2/1 bonus points allocation from bps
+package bonus
+
+import (
+	"sort"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+)
+
+// Score of a player in one fixture, only players with minutes can earn bonus
+type Score struct {
+	Element int
+	Bps     int
+	Minutes int
+}
+
+// Allocate 3/2/1 bonus of one fixture by bps with the official tie rules:
+// tied players share the higher bonus and the next player drops by the tie size,
+// e.g. 3,3,1 for a tie on first, 3,2,2 on second, 3,2,1,1 on third and 3,3,3 on a three-way first
+func Allocate(scores []Score) map[int]int {
+	played := make([]Score, 0, len(scores))
+	for _, s := range scores {
+		if s.Minutes > 0 {
+			played = append(played, s)
+		}
+	}
+	sort.SliceStable(played, func(i, j int) bool { return played[i].Bps > played[j].Bps })
+
+	id2Bonus := make(map[int]int)
+	awarded := 0
+	for i := 0; i < len(played); {
+		bonus := 3 - awarded
+		if bonus <= 0 {
+			break
+		}
+		j := i
+		for j < len(played) && played[j].Bps == played[i].Bps {
+			id2Bonus[played[j].Element] = bonus
+			j++
+		}
+		awarded += j - i
+		i = j
+	}
+	return id2Bonus
+}
+
+// FromFixture bps scores of f, confirmed once the api lists bonus in its stats.
+// Fixture stats only list players with bps, so every one of them counts as played.
+func FromFixture(f fixtures.Fixture) (scores []Score, confirmed bool) {
+	for _, stat := range f.Stats {
+		switch stat.Identifier {
+		case "bps":
+			for _, v := range append(append([]fixtures.StatValue{}, stat.H...), stat.A...) {
+				scores = append(scores, Score{Element: v.Element, Bps: v.Value, Minutes: 1})
+			}
+		case "bonus":
+			confirmed = confirmed || len(stat.H)+len(stat.A) > 0
+		}
+	}
+	return scores, confirmed
+}
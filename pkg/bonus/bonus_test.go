@@ -0,0 +1,64 @@
+package bonus_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/bonus"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+)
+
+// scores of elements 1, 2, ... with bps, every one played
+func scores(bps ...int) []bonus.Score {
+	s := make([]bonus.Score, len(bps))
+	for i, b := range bps {
+		s[i] = bonus.Score{Element: i + 1, Bps: b, Minutes: 90}
+	}
+	return s
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []bonus.Score
+		want   map[int]int
+	}{
+		{name: "no tie", scores: scores(40, 30, 20, 10), want: map[int]int{1: 3, 2: 2, 3: 1}},
+		{name: "two-way tie for first", scores: scores(40, 40, 30, 20), want: map[int]int{1: 3, 2: 3, 3: 1}},
+		{name: "three-way tie for first", scores: scores(40, 40, 40, 30), want: map[int]int{1: 3, 2: 3, 3: 3}},
+		{name: "tie for second", scores: scores(40, 30, 30, 20), want: map[int]int{1: 3, 2: 2, 3: 2}},
+		{name: "tie for third", scores: scores(40, 30, 20, 20, 10), want: map[int]int{1: 3, 2: 2, 3: 1, 4: 1}},
+		{name: "unsorted", scores: scores(20, 40, 10, 30), want: map[int]int{2: 3, 4: 2, 1: 1}},
+		{
+			name:   "no minutes, no bonus",
+			scores: append(scores(40, 30, 20), bonus.Score{Element: 9, Bps: 50}),
+			want:   map[int]int{1: 3, 2: 2, 3: 1},
+		},
+		{name: "fewer than three played", scores: scores(40, 30), want: map[int]int{1: 3, 2: 2}},
+		{name: "none played", want: map[int]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bonus.Allocate(tt.scores); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromFixture(t *testing.T) {
+	f := fixtures.Fixture{Stats: []fixtures.Stat{
+		{Identifier: "bps", H: []fixtures.StatValue{{Element: 1, Value: 30}}, A: []fixtures.StatValue{{Element: 2, Value: 25}}},
+		{Identifier: "bonus"},
+	}}
+	got, confirmed := bonus.FromFixture(f)
+	want := []bonus.Score{{Element: 1, Bps: 30, Minutes: 1}, {Element: 2, Bps: 25, Minutes: 1}}
+	if !reflect.DeepEqual(got, want) || confirmed {
+		t.Errorf("FromFixture() = %v, %v, want %v, false", got, confirmed, want)
+	}
+
+	f.Stats[1].H = []fixtures.StatValue{{Element: 1, Value: 3}}
+	if _, confirmed := bonus.FromFixture(f); !confirmed {
+		t.Errorf("FromFixture() with bonus stats not confirmed")
+	}
+}
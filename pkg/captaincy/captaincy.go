@@ -0,0 +1,204 @@
+// Package captaincy provides ranked captain choices of an entry's XI with a risk profile
+package captaincy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// Mode of a Recommender
+type Mode int
+
+// Safe favours steady players the league captains too, Differential the upside
+// of players the league does not own
+const (
+	Safe Mode = iota
+	Differential
+)
+
+// ParseMode from "safe" or "differential"
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(s) {
+	case "safe":
+		return Safe, nil
+	case "differential":
+		return Differential, nil
+	}
+	return Safe, fmt.Errorf("unknown captaincy mode %q, want safe or differential", s)
+}
+
+// String of m
+func (m Mode) String() string {
+	if m == Differential {
+		return "differential"
+	}
+	return "safe"
+}
+
+// weights of the score components
+const (
+	// difficultyWeight scales projected points by 5% per FDR step from an average 3,
+	// with Recommender.AdjustDifficulty only
+	difficultyWeight = 0.05
+	// riskWeight of the standard deviation, a penalty when safe and a bonus as differential
+	riskWeight = 0.5
+	// coverWeight of projected points times league EO when safe: following the league shields rank
+	coverWeight = 0.25
+)
+
+// Candidate captain with the components of its Score and their Rationale.
+// EffectiveOwnership is a league percentage, Gain the expected points won over
+// the league average by captaining, (2 - EO/100) times adjusted projected points.
+type Candidate struct {
+	PlayerID           int
+	Projected          float64
+	StdDev             float64
+	Fixtures           int
+	Difficulty         float64
+	EffectiveOwnership float64
+	Gain               float64
+	Score              float64
+	Rationale          string
+}
+
+// Recommender ranks captain choices by Projection, the spread of ID2History points
+// and EffectiveOwnership by player among the entry's league rivals, the entry itself
+// left out (percent, empty without a league). Rivals' picks of the next gameweek stay
+// private until its deadline, so their EffectiveOwnership is of the current one. Fixtures
+// difficulty is in every Rationale but only scales a Projection with AdjustDifficulty,
+// for one blind to opponents such as planner.ColumnProjection: projection.Model already
+// weighs team strengths.
+type Recommender struct {
+	Mode               Mode
+	AdjustDifficulty   bool
+	Players            []team.Player
+	ID2History         map[int][]element.History
+	Fixtures           *fixtures.Fixtures
+	Registry           *registry.Registry
+	Projection         func(id, gw int) float64
+	EffectiveOwnership map[int]float64
+}
+
+// Recommend captains among the starters of picks for gameweek gw, best first
+func (r Recommender) Recommend(picks entry.PicksResponse, gw int) []Candidate {
+	id2Team := make(map[int]int, len(r.Players))
+	for _, p := range r.Players {
+		id2Team[p.ID] = p.TeamID
+	}
+	candidates := []Candidate{}
+	for _, pick := range picks.Picks {
+		if pick.Position > entry.StartingPositions {
+			continue
+		}
+		c := Candidate{
+			PlayerID:           pick.Element,
+			Projected:          r.Projection(pick.Element, gw),
+			StdDev:             stdDev(r.ID2History[pick.Element]),
+			EffectiveOwnership: r.EffectiveOwnership[pick.Element],
+		}
+		opponents := []string{}
+		teamID := id2Team[pick.Element]
+		if r.Fixtures != nil {
+			for _, f := range r.Fixtures.Team2Gw2Fixture[teamID][gw] {
+				difficulty, opponent, isHome := fixtures.Difficulty(f, teamID)
+				c.Fixtures++
+				c.Difficulty += float64(difficulty)
+				venue := "A"
+				if isHome {
+					venue = "H"
+				}
+				opponents = append(opponents, fmt.Sprintf("%v(%v) FDR %d", r.Registry.TeamName(opponent), venue, difficulty))
+			}
+		}
+		adjusted := c.Projected
+		if c.Fixtures > 0 {
+			c.Difficulty /= float64(c.Fixtures)
+			if r.AdjustDifficulty {
+				adjusted *= 1 + difficultyWeight*(3-c.Difficulty)
+			}
+		}
+		c.Gain = adjusted * (2 - c.EffectiveOwnership/100)
+		switch r.Mode {
+		case Differential:
+			c.Score = c.Gain/2 + riskWeight*c.StdDev
+		default:
+			c.Score = adjusted - riskWeight*c.StdDev + coverWeight*adjusted*c.EffectiveOwnership/100
+		}
+		c.Rationale = r.rationale(c, opponents)
+		candidates = append(candidates, c)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	return candidates
+}
+
+// rationale of c in words, e.g. "6.1 xP vs ARS(A) FDR 4; steady (sd 2.1); league EO 120%,
+// captaining covers rivals; +4.88 pts vs league"
+func (r Recommender) rationale(c Candidate, opponents []string) string {
+	parts := []string{}
+	switch {
+	case c.Fixtures == 0:
+		parts = append(parts, "blank gameweek")
+	default:
+		parts = append(parts, fmt.Sprintf("%.1f xP vs %v", c.Projected, strings.Join(opponents, ", ")))
+	}
+	if c.Fixtures > 1 {
+		parts = append(parts, "double gameweek")
+	}
+
+	steadiness := "steady"
+	if c.StdDev > 3.5 {
+		steadiness = "volatile"
+	}
+	switch {
+	case r.Mode == Safe && steadiness == "volatile":
+		parts = append(parts, fmt.Sprintf("volatile (sd %.1f) so penalised", c.StdDev))
+	case r.Mode == Differential && steadiness == "volatile":
+		parts = append(parts, fmt.Sprintf("volatile (sd %.1f) for upside", c.StdDev))
+	default:
+		parts = append(parts, fmt.Sprintf("%v (sd %.1f)", steadiness, c.StdDev))
+	}
+
+	switch {
+	case len(r.EffectiveOwnership) == 0:
+	case c.EffectiveOwnership >= 100 && r.Mode == Safe:
+		parts = append(parts, fmt.Sprintf("league EO %.0f%%, captaining covers rivals", c.EffectiveOwnership))
+	case c.EffectiveOwnership >= 100:
+		parts = append(parts, fmt.Sprintf("league EO %.0f%%, little to gain on rivals", c.EffectiveOwnership))
+	case c.EffectiveOwnership < 30:
+		parts = append(parts, fmt.Sprintf("league EO %.0f%%, a true differential", c.EffectiveOwnership))
+	default:
+		parts = append(parts, fmt.Sprintf("league EO %.0f%%", c.EffectiveOwnership))
+	}
+	parts = append(parts, fmt.Sprintf("%+.2f pts vs league", c.Gain))
+	return strings.Join(parts, "; ")
+}
+
+// stdDev of points over matches played in historyList, 0 with fewer than 2
+func stdDev(historyList []element.History) float64 {
+	points := []float64{}
+	for _, h := range historyList {
+		if h.Minutes > 0 {
+			points = append(points, float64(h.TotalPoints))
+		}
+	}
+	if len(points) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, p := range points {
+		mean += p / float64(len(points))
+	}
+	variance := 0.0
+	for _, p := range points {
+		variance += (p - mean) * (p - mean)
+	}
+	return math.Sqrt(variance / float64(len(points)-1))
+}
@@ -0,0 +1,125 @@
+package captaincy_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/captaincy"
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/ownership"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+func TestRecommendDifficulty(t *testing.T) {
+	players := []team.Player{{ID: 1, TeamID: 1}, {ID: 2, TeamID: 2}}
+	teams := []team.Team{{ID: 1, ShortName: "EAS"}, {ID: 2, ShortName: "HAR"}, {ID: 3, ShortName: "WEA"}, {ID: 4, ShortName: "STR"}}
+	easy := fixtures.Fixture{Event: 5, TeamH: 1, TeamA: 3, TeamHDifficulty: 2, TeamADifficulty: 4}
+	hard := fixtures.Fixture{Event: 5, TeamH: 4, TeamA: 2, TeamHDifficulty: 2, TeamADifficulty: 5}
+	x := &fixtures.Fixtures{Team2Gw2Fixture: map[int]map[int][]fixtures.Fixture{
+		1: {5: {easy}},
+		2: {5: {hard}},
+	}}
+	// the harder fixture has the higher projection, as a model weighing strengths would not give
+	projected := map[int]float64{1: 6, 2: 6.2}
+	picks := entry.PicksResponse{Picks: []entry.Pick{{Element: 1, Position: 1}, {Element: 2, Position: 2}}}
+
+	tests := []struct {
+		name             string
+		adjustDifficulty bool
+		wantFirst        int
+	}{
+		{name: "model projection kept", wantFirst: 2},
+		{name: "column projection adjusted", adjustDifficulty: true, wantFirst: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := captaincy.Recommender{
+				AdjustDifficulty: tt.adjustDifficulty,
+				Players:          players,
+				Fixtures:         x,
+				Registry:         registry.New(players, teams, nil),
+				Projection:       func(id, gw int) float64 { return projected[id] },
+			}
+			candidates := r.Recommend(picks, 5)
+			if len(candidates) != 2 {
+				t.Fatalf("Recommend() of %d candidates, want 2", len(candidates))
+			}
+			if candidates[0].PlayerID != tt.wantFirst {
+				t.Errorf("Recommend() first %d, want %d", candidates[0].PlayerID, tt.wantFirst)
+			}
+			for _, c := range candidates {
+				if !tt.adjustDifficulty && c.Score != c.Projected {
+					t.Errorf("player %d Score %v, want its projection %v", c.PlayerID, c.Score, c.Projected)
+				}
+				if !strings.Contains(c.Rationale, "FDR") {
+					t.Errorf("player %d rationale %q without FDR", c.PlayerID, c.Rationale)
+				}
+			}
+		})
+	}
+}
+
+func TestRecommendMode(t *testing.T) {
+	players := []team.Player{{ID: 1, TeamID: 1}, {ID: 2, TeamID: 2}}
+	steady, volatile := []element.History{}, []element.History{}
+	for gw := 1; gw <= 4; gw++ {
+		steady = append(steady, element.History{Round: gw, Minutes: 90, TotalPoints: 6})
+		volatile = append(volatile, element.History{Round: gw, Minutes: 90, TotalPoints: 12 * (gw % 2)})
+	}
+	// 1 is steady and the league's captain, 2 volatile and owned by no rival
+	projected := map[int]float64{1: 6, 2: 5.5}
+	picks := entry.PicksResponse{Picks: []entry.Pick{{Element: 1, Position: 1}, {Element: 2, Position: 2}}}
+
+	tests := []struct {
+		mode      captaincy.Mode
+		wantFirst int
+	}{
+		{mode: captaincy.Safe, wantFirst: 1},
+		{mode: captaincy.Differential, wantFirst: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			r := captaincy.Recommender{
+				Mode:               tt.mode,
+				Players:            players,
+				ID2History:         map[int][]element.History{1: steady, 2: volatile},
+				Projection:         func(id, gw int) float64 { return projected[id] },
+				EffectiveOwnership: map[int]float64{1: 150},
+			}
+			candidates := r.Recommend(picks, 5)
+			if len(candidates) != 2 || candidates[0].PlayerID != tt.wantFirst {
+				t.Fatalf("Recommend() = %+v, want %d first", candidates, tt.wantFirst)
+			}
+			for _, c := range candidates {
+				if want := projected[c.PlayerID] * (2 - r.EffectiveOwnership[c.PlayerID]/100); c.Gain != want {
+					t.Errorf("player %d Gain %v, want %v", c.PlayerID, c.Gain, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRecommendRivalsGain(t *testing.T) {
+	const me = 1
+	league := map[int]entry.PicksResponse{
+		me: {Picks: []entry.Pick{{Element: 1, Position: 1, IsCaptain: true}, {Element: 2, Position: 2}}},
+		2:  {Picks: []entry.Pick{{Element: 1, Position: 1}, {Element: 3, Position: 2, IsCaptain: true}}},
+	}
+	r := captaincy.Recommender{
+		Players:            []team.Player{{ID: 1, TeamID: 1}, {ID: 2, TeamID: 2}},
+		Projection:         func(id, gw int) float64 { return 6 },
+		EffectiveOwnership: ownership.ByPlayer(ownership.Calculate(4, ownership.Rivals(league, me))),
+	}
+	id2Gain := make(map[int]float64)
+	for _, c := range r.Recommend(league[me], 5) {
+		id2Gain[c.PlayerID] = c.Gain
+	}
+	// the rival starts 1, my own captaincy of him left out
+	if want := map[int]float64{1: 6, 2: 12}; !reflect.DeepEqual(id2Gain, want) {
+		t.Errorf("Gain by player %v, want %v", id2Gain, want)
+	}
+}
@@ -0,0 +1,51 @@
+package captaincy
+
+import (
+	"fmt"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+)
+
+// Row of a Candidate for csv.StructSlice
+type Row struct {
+	Entry              int
+	Gameweek           int
+	Mode               string
+	Rank               int
+	WebName            string
+	TeamName           string
+	Projected          float64
+	StdDev             float64
+	Difficulty         float64
+	EffectiveOwnership float64
+	Gain               float64
+	Score              float64
+	Rationale          string
+}
+
+// EntryToCsv ranked candidates of entryID for gameweek gw
+func (r Recommender) EntryToCsv(entryID, gw int, candidates []Candidate) error {
+	id2Team := make(map[int]int, len(r.Players))
+	for _, p := range r.Players {
+		id2Team[p.ID] = p.TeamID
+	}
+	rows := make([]Row, len(candidates))
+	for i, c := range candidates {
+		rows[i] = Row{
+			Entry:              entryID,
+			Gameweek:           gw,
+			Mode:               r.Mode.String(),
+			Rank:               i + 1,
+			WebName:            r.Registry.PlayerName(c.PlayerID),
+			TeamName:           r.Registry.TeamName(id2Team[c.PlayerID]),
+			Projected:          c.Projected,
+			StdDev:             c.StdDev,
+			Difficulty:         c.Difficulty,
+			EffectiveOwnership: c.EffectiveOwnership,
+			Gain:               c.Gain,
+			Score:              c.Score,
+			Rationale:          c.Rationale,
+		}
+	}
+	return csv.StructSlice(rows, fmt.Sprintf("fpl-entries/%d-captaincy-%v", entryID, r.Mode))
+}
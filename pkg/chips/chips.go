@@ -0,0 +1,251 @@
+// Package chips provides the expected value of playing each chip in each remaining gameweek
+package chips
+
+import (
+	"context"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+	"github.com/jadugnap/golang-fpl-101/pkg/optimizer"
+	"github.com/jadugnap/golang-fpl-101/pkg/planner"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// DefaultWildcardHorizon gameweeks a wildcard squad is picked for by a zero Simulator
+const DefaultWildcardHorizon = 4
+
+// wildcardBenchWeight of the bench in a wildcard squad
+const wildcardBenchWeight = 0.1
+
+// DefaultHalfEnd last gameweek of the first half of the season when bootstrap-static
+// lists no chips, the first set of chips expires with it
+const DefaultHalfEnd = 19
+
+// Names of every chip in the order they are simulated
+var Names = []string{entry.Wildcard, entry.FreeHit, entry.BenchBoost, entry.TripleCaptain}
+
+// Chip of a half of the season, every chip is given once per half
+type Chip struct {
+	Name string
+	Half int
+}
+
+// HalfEnd of the season in res, the last stop_event of its first set of chips,
+// DefaultHalfEnd without any
+func HalfEnd(res fpl.Response) int {
+	halfEnd := 0
+	for _, chip := range res.Chips {
+		if chip.Number == 1 && chip.StopEvent > halfEnd {
+			halfEnd = chip.StopEvent
+		}
+	}
+	if halfEnd == 0 {
+		return DefaultHalfEnd
+	}
+	return halfEnd
+}
+
+// Half of the season gameweek gw is in, 1 up to halfEnd then 2
+func Half(gw, halfEnd int) int {
+	if gw <= halfEnd {
+		return 1
+	}
+	return 2
+}
+
+// Timing of a chip in a gameweek. Value is the expected points over not playing it,
+// over the next WildcardHorizon gameweeks for a wildcard. SquadBlanks and SquadDoubles
+// count squad players without a fixture or with two in the gameweek.
+type Timing struct {
+	Chip         string
+	Gameweek     int
+	Value        float64
+	SquadBlanks  int
+	SquadDoubles int
+	Best         bool
+}
+
+// Simulator of chip timings for a squad, with Projection of every player's points.
+// A free hit or wildcard squad is bought with the squad sold at its selling prices
+// after SellOnFee. HalfEnd splits the season's chips, DefaultHalfEnd when 0.
+type Simulator struct {
+	Rules           optimizer.Rules
+	HalfEnd         int
+	SellOnFee       float64
+	Players         []team.Player
+	Fixtures        *fixtures.Fixtures
+	Projection      func(id, gw int) float64
+	WildcardHorizon int
+}
+
+// Available chips of an entry, those of each half up to halfEnd and after not played in it yet
+func Available(history entry.HistoryResponse, halfEnd int) []Chip {
+	played := make(map[Chip]bool)
+	for _, chip := range history.Chips {
+		played[Chip{Name: chip.Name, Half: Half(chip.Event, halfEnd)}] = true
+	}
+	available := []Chip{}
+	for half := 1; half <= 2; half++ {
+		for _, name := range Names {
+			if c := (Chip{Name: name, Half: half}); !played[c] {
+				available = append(available, c)
+			}
+		}
+	}
+	return available
+}
+
+// CrowdUsage share of managers who played each chip so far, from the events' chip_plays
+func CrowdUsage(res fpl.Response) map[string]float64 {
+	usage := make(map[string]float64)
+	if res.TotalPlayers == 0 {
+		return usage
+	}
+	for _, event := range res.Events {
+		for _, play := range event.ChipPlays {
+			usage[play.ChipName] += float64(play.NumPlayed) / float64(res.TotalPlayers)
+		}
+	}
+	return usage
+}
+
+// Simulate every available chip in every gameweek of its half for the squad of start,
+// by chip then gameweek, each chip's most valuable gameweek of each half flagged Best.
+// A free hit or wildcard squad is the optimizer's best within the funds of start, a
+// wildcard one over the WildcardHorizon gameweeks from its own, up to the last one
+// with fixtures, however few gameweeks are listed.
+func (s Simulator) Simulate(ctx context.Context, start planner.State, available []Chip, gameweeks []int) ([]Timing, error) {
+	horizon := s.WildcardHorizon
+	if horizon == 0 {
+		horizon = DefaultWildcardHorizon
+	}
+	halfEnd := s.HalfEnd
+	if halfEnd == 0 {
+		halfEnd = DefaultHalfEnd
+	}
+	id2Player := make(map[int]team.Player, len(s.Players))
+	for _, p := range s.Players {
+		id2Player[p.ID] = p
+	}
+	squad := start.Squad
+	budget := start.Funds(s.Players, s.SellOnFee)
+	isAvailable := make(map[Chip]bool, len(available))
+	for _, chip := range available {
+		isAvailable[chip] = true
+	}
+	last := s.Fixtures.LastGameweek()
+
+	timings := []Timing{}
+	for _, chip := range Names {
+		for _, gw := range gameweeks {
+			if !isAvailable[Chip{Name: chip, Half: Half(gw, halfEnd)}] {
+				continue
+			}
+			t := Timing{Chip: chip, Gameweek: gw}
+			for _, id := range squad {
+				switch n := s.Fixtures.FixtureCount(id2Player[id].TeamID, gw); {
+				case n == 0:
+					t.SquadBlanks++
+				case n > 1:
+					t.SquadDoubles++
+				}
+			}
+			current := s.lineup(squad, gw, id2Player)
+			switch chip {
+			case entry.BenchBoost:
+				for _, p := range current.Picks {
+					if !p.Starter {
+						t.Value += p.Score
+					}
+				}
+			case entry.TripleCaptain:
+				t.Value = captain(current)
+			case entry.FreeHit:
+				best, err := s.best(ctx, budget, 0, []int{gw})
+				if err != nil {
+					return nil, err
+				}
+				t.Value = s.points(best, gw, id2Player) - s.points(squad, gw, id2Player)
+			case entry.Wildcard:
+				window := []int{gw}
+				for g := gw + 1; g < gw+horizon && g <= last; g++ {
+					window = append(window, g)
+				}
+				best, err := s.best(ctx, budget, wildcardBenchWeight, window)
+				if err != nil {
+					return nil, err
+				}
+				for _, g := range window {
+					t.Value += s.points(best, g, id2Player) - s.points(squad, g, id2Player)
+				}
+			}
+			timings = append(timings, t)
+		}
+	}
+	flagBest(timings, halfEnd)
+	return timings, nil
+}
+
+// best squad within budget by projected points summed over gameweeks
+func (s Simulator) best(ctx context.Context, budget int, benchWeight float64, gameweeks []int) ([]int, error) {
+	rules := s.Rules
+	rules.Budget = budget
+	candidates := optimizer.CandidatesFunc(s.Players, func(p team.Player) float64 {
+		points := 0.0
+		for _, gw := range gameweeks {
+			points += s.Projection(p.ID, gw)
+		}
+		return points
+	})
+	sq, err := optimizer.Optimizer{Rules: rules, BenchWeight: benchWeight}.Solve(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(sq.Picks))
+	for i, p := range sq.Picks {
+		ids[i] = p.ID
+	}
+	return ids, nil
+}
+
+// points of squad in gw: its best XI with the top starter as captain
+func (s Simulator) points(squad []int, gw int, id2Player map[int]team.Player) float64 {
+	sq := s.lineup(squad, gw, id2Player)
+	return sq.XIScore + captain(sq)
+}
+
+// lineup of squad in gw by projection: its best XI and bench
+func (s Simulator) lineup(squad []int, gw int, id2Player map[int]team.Player) optimizer.Squad {
+	candidates := make([]optimizer.Candidate, 0, len(squad))
+	for _, id := range squad {
+		p := id2Player[id]
+		candidates = append(candidates, optimizer.Candidate{ID: id, TeamID: p.TeamID, RoleID: p.RoleID, Cost: p.NowCost, Score: s.Projection(id, gw)})
+	}
+	return optimizer.Optimizer{Rules: s.Rules}.Lineup(candidates)
+}
+
+// captain points of the best starter of sq
+func captain(sq optimizer.Squad) float64 {
+	best := 0.0
+	for _, p := range sq.Picks {
+		if p.Starter && p.Score > best {
+			best = p.Score
+		}
+	}
+	return best
+}
+
+// flagBest timing of every chip in each half, the first one up to halfEnd
+func flagBest(timings []Timing, halfEnd int) {
+	best := make(map[Chip]int)
+	for i, t := range timings {
+		c := Chip{Name: t.Chip, Half: Half(t.Gameweek, halfEnd)}
+		if j, ok := best[c]; !ok || t.Value > timings[j].Value {
+			best[c] = i
+		}
+	}
+	for _, i := range best {
+		timings[i].Best = true
+	}
+}
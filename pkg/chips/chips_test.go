@@ -0,0 +1,187 @@
+package chips_test
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/chips"
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpltest"
+	"github.com/jadugnap/golang-fpl-101/pkg/optimizer"
+	"github.com/jadugnap/golang-fpl-101/pkg/planner"
+)
+
+func TestAvailable(t *testing.T) {
+	tests := []struct {
+		name       string
+		halfEnd    int
+		played     []entry.Chip
+		wantPlayed []chips.Chip
+	}{
+		{name: "none played"},
+		{
+			name:       "first half wildcard",
+			played:     []entry.Chip{{Name: entry.Wildcard, Event: 5}},
+			wantPlayed: []chips.Chip{{Name: entry.Wildcard, Half: 1}},
+		},
+		{
+			name:       "both wildcards",
+			played:     []entry.Chip{{Name: entry.Wildcard, Event: 5}, {Name: entry.Wildcard, Event: 25}},
+			wantPlayed: []chips.Chip{{Name: entry.Wildcard, Half: 1}, {Name: entry.Wildcard, Half: 2}},
+		},
+		{
+			name:       "half boundary",
+			played:     []entry.Chip{{Name: entry.FreeHit, Event: chips.DefaultHalfEnd}, {Name: entry.BenchBoost, Event: chips.DefaultHalfEnd + 1}},
+			wantPlayed: []chips.Chip{{Name: entry.FreeHit, Half: 1}, {Name: entry.BenchBoost, Half: 2}},
+		},
+		{
+			name:       "later half boundary",
+			halfEnd:    21,
+			played:     []entry.Chip{{Name: entry.FreeHit, Event: 21}, {Name: entry.BenchBoost, Event: 22}},
+			wantPlayed: []chips.Chip{{Name: entry.FreeHit, Half: 1}, {Name: entry.BenchBoost, Half: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			halfEnd := tt.halfEnd
+			if halfEnd == 0 {
+				halfEnd = chips.DefaultHalfEnd
+			}
+			available := chips.Available(entry.HistoryResponse{Chips: tt.played}, halfEnd)
+			isAvailable := make(map[chips.Chip]bool, len(available))
+			for _, c := range available {
+				isAvailable[c] = true
+			}
+			played := []chips.Chip{}
+			for half := 1; half <= 2; half++ {
+				for _, name := range chips.Names {
+					if c := (chips.Chip{Name: name, Half: half}); !isAvailable[c] {
+						played = append(played, c)
+					}
+				}
+			}
+			if len(played) != len(tt.wantPlayed) || (len(played) > 0 && !reflect.DeepEqual(played, tt.wantPlayed)) {
+				t.Errorf("Available() misses %v, want %v", played, tt.wantPlayed)
+			}
+		})
+	}
+}
+
+func TestHalfEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		chips []fpl.Chip
+		want  int
+	}{
+		{name: "no chips listed", want: chips.DefaultHalfEnd},
+		{
+			name: "first set's stop_event",
+			chips: []fpl.Chip{
+				{Name: entry.Wildcard, Number: 1, StartEvent: 2, StopEvent: 20},
+				{Name: entry.BenchBoost, Number: 1, StartEvent: 1, StopEvent: 20},
+				{Name: entry.Wildcard, Number: 2, StartEvent: 21, StopEvent: 38},
+			},
+			want: 20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chips.HalfEnd(fpl.Response{Chips: tt.chips}); got != tt.want {
+				t.Errorf("HalfEnd() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	season := fpltest.NewSeason(1, 6, 22, 2)
+	if err := season.AddEntry(1, "me", 7); err != nil {
+		t.Fatal(err)
+	}
+	srv := fpltest.NewServer(season)
+	defer srv.Close()
+	x := &fixtures.Fixtures{Client: client.GenericClient{Endpoint: srv.FixturesEndpoint()}}
+	if err := x.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	res := season.Bootstrap
+	sim := chips.Simulator{
+		Rules:     optimizer.NewRules(res.GameSettings, res.PlayerRoles),
+		SellOnFee: res.GameSettings.TransfersSellOnFee,
+		Players:   res.Players,
+		Fixtures:  x,
+		// the same points every gameweek, a wildcard is worth its window's length
+		Projection:      func(id, gw int) float64 { return float64(id%7) + float64(id%3)/2 },
+		WildcardHorizon: 4,
+	}
+	start := planner.State{Bank: season.Picks[1][2].EntryHistory.Bank}
+	for _, pick := range season.Picks[1][2].Picks {
+		start.Squad = append(start.Squad, pick.Element)
+	}
+	wildcard := []chips.Chip{{Name: entry.Wildcard, Half: 1}, {Name: entry.Wildcard, Half: 2}}
+	value := func(available []chips.Chip, gameweeks []int, chip string, gw int) float64 {
+		t.Helper()
+		timings, err := sim.Simulate(context.Background(), start, available, gameweeks)
+		if err != nil {
+			t.Fatalf("Simulate() error = %v", err)
+		}
+		for _, timing := range timings {
+			if timing.Chip == chip && timing.Gameweek == gw {
+				return timing.Value
+			}
+		}
+		t.Fatalf("Simulate(%v) has no %v in GW%d", gameweeks, chip, gw)
+		return 0
+	}
+
+	full := value(wildcard, []int{3, 4, 5, 6, 7}, entry.Wildcard, 3)
+	if full <= 0 {
+		t.Fatalf("wildcard in GW3 worth %v, want a gain", full)
+	}
+	if got := value(wildcard, []int{3}, entry.Wildcard, 3); math.Abs(got-full) > 1e-9 {
+		t.Errorf("wildcard in GW3 worth %v listed alone, %v among more gameweeks", got, full)
+	}
+	if got := value(wildcard, []int{21}, entry.Wildcard, 21); math.Abs(got-full/2) > 1e-9 {
+		t.Errorf("wildcard in GW21 worth %v over the last 2 gameweeks, want %v", got, full/2)
+	}
+
+	// a squad bought before a rise sells for less than its current value
+	bought := start
+	bought.PurchasePrice = make(map[int]int)
+	for _, id := range start.Squad {
+		bought.PurchasePrice[id] = res.Players[id-1].NowCost - 10
+	}
+	if funds, value := bought.Funds(res.Players, sim.SellOnFee), start.Funds(res.Players, sim.SellOnFee); funds != value-5*len(start.Squad) {
+		t.Errorf("Funds() = %d, want %d less than %d", funds, 5*len(start.Squad), value)
+	}
+
+	timings, err := sim.Simulate(context.Background(), start, []chips.Chip{{Name: entry.BenchBoost, Half: 1}}, []int{18, 19, 20, 21})
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	gameweeks := []int{}
+	for _, timing := range timings {
+		gameweeks = append(gameweeks, timing.Gameweek)
+	}
+	if want := []int{18, 19}; !reflect.DeepEqual(gameweeks, want) {
+		t.Errorf("first half bench boost simulated in %v, want %v", gameweeks, want)
+	}
+
+	sim.HalfEnd = 20
+	timings, err = sim.Simulate(context.Background(), start, []chips.Chip{{Name: entry.BenchBoost, Half: 1}}, []int{18, 19, 20, 21})
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	gameweeks = []int{}
+	for _, timing := range timings {
+		gameweeks = append(gameweeks, timing.Gameweek)
+	}
+	if want := []int{18, 19, 20}; !reflect.DeepEqual(gameweeks, want) {
+		t.Errorf("first half bench boost to GW20 simulated in %v, want %v", gameweeks, want)
+	}
+}
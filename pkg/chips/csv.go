@@ -0,0 +1,37 @@
+package chips
+
+import (
+	"fmt"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+)
+
+// Row of a Timing for csv.StructSlice, CrowdUsed is the percent of managers who played the chip already
+type Row struct {
+	Entry        int
+	Chip         string
+	Gameweek     int
+	Value        float64
+	SquadBlanks  int
+	SquadDoubles int
+	Best         bool
+	CrowdUsed    float64
+}
+
+// EntryToCsv timings of entryID with the crowd usage of each chip
+func EntryToCsv(entryID int, timings []Timing, crowdUsage map[string]float64) error {
+	rows := make([]Row, len(timings))
+	for i, t := range timings {
+		rows[i] = Row{
+			Entry:        entryID,
+			Chip:         t.Chip,
+			Gameweek:     t.Gameweek,
+			Value:        t.Value,
+			SquadBlanks:  t.SquadBlanks,
+			SquadDoubles: t.SquadDoubles,
+			Best:         t.Best,
+			CrowdUsed:    crowdUsage[t.Chip] * 100,
+		}
+	}
+	return csv.StructSlice(rows, fmt.Sprintf("fpl-entries/%d-chips", entryID))
+}
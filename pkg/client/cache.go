@@ -0,0 +1,130 @@
+package client
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultTTLRules for the fpl api, bootstrap-static changes hourly at most. element-summary
+// history of the live gameweek changes with every match, so it is kept short and an
+// unchanged player costs a 304 on revalidation rather than a full body. No rule keeps
+// finished data for a day: the api serves past and live gameweeks from the same URLs,
+// element-summary and entry/{id}/history/ listing the live gameweek next to the finished
+// ones, so a URL alone never tells that its body is final and revalidation stays cheap.
+var (
+	DefaultTTLRules = []TTLRule{
+		{Pattern: "/api/bootstrap-static/", TTL: time.Hour},
+		{Pattern: "/api/element-summary/", TTL: 15 * time.Minute},
+	}
+)
+
+// Cache stores response bodies on disk keyed by URL and revalidates them with ETag/Last-Modified
+type Cache struct {
+	Dir        string
+	Rules      []TTLRule
+	DefaultTTL time.Duration
+}
+
+// TTLRule applies TTL to every URL containing Pattern, first matching rule wins
+type TTLRule struct {
+	Pattern string
+	TTL     time.Duration
+}
+
+// cacheEntry as stored next to the cached body
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag"`
+	LastModified string    `json:"last_modified"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Body         []byte    `json:"-"`
+}
+
+// NewCache in dir with DefaultTTLRules
+func NewCache(dir string) *Cache {
+	return &Cache{
+		Dir:   dir,
+		Rules: DefaultTTLRules,
+	}
+}
+
+// TTL of url according to Rules, DefaultTTL otherwise
+func (c *Cache) TTL(url string) time.Duration {
+	for _, rule := range c.Rules {
+		if strings.Contains(url, rule.Pattern) {
+			return rule.TTL
+		}
+	}
+	return c.DefaultTTL
+}
+
+// lookup cached entry of url, fresh when still within its TTL
+func (c *Cache) lookup(url string) (entry *cacheEntry, fresh bool) {
+	if c == nil {
+		return nil, false
+	}
+	metaBytes, err := ioutil.ReadFile(c.path(url, ".meta.json"))
+	if err != nil {
+		return nil, false
+	}
+	entry = &cacheEntry{}
+	if err := json.Unmarshal(metaBytes, entry); err != nil || entry.URL != url {
+		return nil, false
+	}
+	if entry.Body, err = ioutil.ReadFile(c.path(url, ".body")); err != nil {
+		return nil, false
+	}
+	return entry, time.Since(entry.FetchedAt) < c.TTL(url)
+}
+
+// store bodyBytes of url with validators from header
+func (c *Cache) store(url string, bodyBytes []byte, header http.Header, previous *cacheEntry) {
+	if c == nil {
+		return
+	}
+	entry := cacheEntry{
+		URL:          url,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}
+	// a 304 may omit validators, keep the ones we revalidated with
+	if previous != nil {
+		if entry.ETag == "" {
+			entry.ETag = previous.ETag
+		}
+		if entry.LastModified == "" {
+			entry.LastModified = previous.LastModified
+		}
+	}
+	metaBytes, err := json.Marshal(entry)
+	if err != nil {
+		log.Println("error json.Marshal():", err)
+		return
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		log.Println("error os.MkdirAll():", err)
+		return
+	}
+	if err := ioutil.WriteFile(c.path(url, ".body"), bodyBytes, 0644); err != nil {
+		log.Println("error ioutil.WriteFile():", err)
+		return
+	}
+	if err := ioutil.WriteFile(c.path(url, ".meta.json"), metaBytes, 0644); err != nil {
+		log.Println("error ioutil.WriteFile():", err)
+	}
+}
+
+// path of the cache file for url with the given suffix
+func (c *Cache) path(url, suffix string) string {
+	sum := sha1.Sum([]byte(url))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+suffix)
+}
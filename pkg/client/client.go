@@ -1,30 +1,205 @@
+// Package client provides a GenericClient to fetch fpl api responses
 package client
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"log"
+	"math"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultRetry when GenericClient.Retry is left empty
+var (
+	DefaultRetry = RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+	}
 )
 
 // GenericClient as the name suggests
 type GenericClient struct {
 	HTTPClient http.Client
 	Endpoint   string
+	Retry      RetryPolicy
+	Limiter    *Limiter
+	Cache      *Cache
+	Tape       *Tape
+}
+
+// RetryPolicy for transient errors, delays grow exponentially with full jitter
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Fetch Endpoint body from Tape on Replay, from Cache when fresh,
+// otherwise from network retrying transient errors. Tape on Record saves the body.
+// input: context.Context
+// output: []byte, *TransportError | *StatusError | ErrNotRecorded | ctx.Err()
+func (c *GenericClient) Fetch(ctx context.Context) ([]byte, error) {
+	if c.Tape != nil && c.Tape.Mode == Replay {
+		return c.Tape.load(c.Endpoint)
+	}
+	bodyBytes, err := c.fetch(ctx)
+	if err == nil && c.Tape != nil && c.Tape.Mode == Record {
+		if err := c.Tape.save(c.Endpoint, bodyBytes); err != nil {
+			log.Println("error Tape.save():", err)
+		}
+	}
+	return bodyBytes, err
+}
+
+// fetch from Cache when fresh, otherwise from network retrying transient errors
+func (c *GenericClient) fetch(ctx context.Context) ([]byte, error) {
+	cached, fresh := c.Cache.lookup(c.Endpoint)
+	if fresh {
+		return cached.Body, nil
+	}
+
+	policy := c.Retry
+	if policy.MaxAttempts <= 0 {
+		policy = DefaultRetry
+	}
+
+	var err error
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			if waitErr := sleep(ctx, policy.delay(attempt, err)); waitErr != nil {
+				return nil, waitErr
+			}
+		}
+		var bodyBytes []byte
+		var header http.Header
+		bodyBytes, header, err = c.fetchOnce(ctx, cached)
+		if err == nil {
+			// a truncated or html 200 must not be served as fresh for a whole TTL
+			if json.Valid(bodyBytes) {
+				c.Cache.store(c.Endpoint, bodyBytes, header, cached)
+			}
+			return bodyBytes, nil
+		}
+		if !isTransient(ctx, err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// FetchJSON from Endpoint and unmarshal into v
+// output: *DecodeError on top of Fetch errors
+func (c *GenericClient) FetchJSON(ctx context.Context, v interface{}) error {
+	bodyBytes, err := c.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bodyBytes, v); err != nil {
+		return &DecodeError{URL: c.Endpoint, Err: err}
+	}
+	return nil
 }
 
-// GetResponse in general []byte
-// input: *GenericClient
-// output: []byte
-func (c *GenericClient) GetResponse() []byte {
-	req, _ := http.NewRequest(http.MethodGet, c.Endpoint, nil)
+// fetchOnce without any retry, revalidating cached when given
+func (c *GenericClient) fetchOnce(ctx context.Context, cached *cacheEntry) ([]byte, http.Header, error) {
+	if err := c.Limiter.Wait(ctx); err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Endpoint, nil)
+	if err != nil {
+		return nil, nil, &TransportError{URL: c.Endpoint, Err: err}
+	}
 	// any non-default "User-Agent", to resolve empty response bug
 	req.Header.Set("User-Agent", "")
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		log.Println("error HTTPClient.Do(req):", err)
-		return nil
+		return nil, nil, &TransportError{URL: c.Endpoint, Err: err}
 	}
 	defer resp.Body.Close()
-	bodyBytes, _ := ioutil.ReadAll(resp.Body)
-	return bodyBytes
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return cached.Body, resp.Header, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, &StatusError{
+			URL:        c.Endpoint,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, &TransportError{URL: c.Endpoint, Err: err}
+	}
+	return bodyBytes, resp.Header, nil
+}
+
+// isTransient when err is worth another attempt
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	var transportErr *TransportError
+	return errors.As(err, &transportErr)
+}
+
+// delay before the given attempt, Retry-After takes precedence over backoff,
+// both capped at MaxDelay
+func (p RetryPolicy) delay(attempt int, lastErr error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(lastErr, &statusErr) && statusErr.RetryAfter > 0 {
+		if p.MaxDelay > 0 && statusErr.RetryAfter > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return statusErr.RetryAfter
+	}
+	backoff := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.MaxDelay > 0 && backoff > float64(p.MaxDelay) {
+		backoff = float64(p.MaxDelay)
+	}
+	return time.Duration(rand.Float64() * backoff)
+}
+
+// parseRetryAfter in either delay-seconds or http-date format
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// sleep for d unless ctx is done first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
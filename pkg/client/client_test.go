@@ -0,0 +1,266 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpltest"
+)
+
+// testRetry keeps backoff short enough for tests
+var testRetry = client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestFetchJSON(t *testing.T) {
+	srv := fpltest.NewServer(fpltest.NewSeason(1, 6, 4, 2))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		endpoint string
+		faults   fpltest.Faults
+		wantHits int
+		wantErr  func(error) bool
+	}{
+		{
+			name:     "ok",
+			endpoint: srv.BootstrapEndpoint(),
+			wantHits: 1,
+		},
+		{
+			name:     "transient status retried",
+			endpoint: srv.BootstrapEndpoint(),
+			faults:   fpltest.Faults{FailFirst: 2, FailStatus: http.StatusServiceUnavailable},
+			wantHits: 3,
+		},
+		{
+			name:     "Retry-After capped at MaxDelay",
+			endpoint: srv.BootstrapEndpoint(),
+			faults:   fpltest.Faults{FailFirst: 2, FailStatus: http.StatusServiceUnavailable, RetryAfter: "3600"},
+			wantHits: 3,
+		},
+		{
+			name:     "transient status exhausts attempts",
+			endpoint: srv.BootstrapEndpoint(),
+			faults:   fpltest.Faults{FailFirst: 10, FailStatus: http.StatusTooManyRequests},
+			wantHits: 3,
+			wantErr: func(err error) bool {
+				var statusErr *client.StatusError
+				return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests && statusErr.Temporary()
+			},
+		},
+		{
+			name:     "permanent status not retried",
+			endpoint: srv.BootstrapEndpoint(),
+			faults:   fpltest.Faults{FailFirst: 10, FailStatus: http.StatusForbidden},
+			wantHits: 1,
+			wantErr: func(err error) bool {
+				var statusErr *client.StatusError
+				return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusForbidden && !statusErr.Temporary()
+			},
+		},
+		{
+			name:     "not found",
+			endpoint: srv.URL + "/api/unknown/",
+			wantHits: 1,
+			wantErr: func(err error) bool {
+				var statusErr *client.StatusError
+				return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+			},
+		},
+		{
+			name:     "truncated body",
+			endpoint: srv.BootstrapEndpoint(),
+			faults:   fpltest.Faults{Truncate: true},
+			wantHits: 1,
+			wantErr: func(err error) bool {
+				var decodeErr *client.DecodeError
+				return errors.As(err, &decodeErr)
+			},
+		},
+		{
+			name:     "transport error retried",
+			endpoint: "http://127.0.0.1:1/api/bootstrap-static/",
+			wantErr: func(err error) bool {
+				var transportErr *client.TransportError
+				return errors.As(err, &transportErr)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.SetFaults(tt.faults)
+			c := client.GenericClient{Endpoint: tt.endpoint, Retry: testRetry}
+			res := fpl.Response{}
+			err := c.FetchJSON(context.Background(), &res)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("FetchJSON() error = %v", err)
+			case tt.wantErr != nil && !tt.wantErr(err):
+				t.Fatalf("FetchJSON() error = %v (%T), not the expected one", err, err)
+			}
+			if tt.wantErr == nil && len(res.Players) == 0 {
+				t.Errorf("FetchJSON() decoded no players")
+			}
+			if got := srv.Hits("/api/"); got != tt.wantHits {
+				t.Errorf("hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestFetchContextCanceled(t *testing.T) {
+	srv := fpltest.NewServer(fpltest.NewSeason(1, 6, 4, 2))
+	defer srv.Close()
+	srv.SetFaults(fpltest.Faults{FailFirst: 10, FailStatus: http.StatusServiceUnavailable, RetryAfter: "60"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	retry := testRetry
+	retry.MaxDelay = time.Minute
+	c := client.GenericClient{Endpoint: srv.BootstrapEndpoint(), Retry: retry}
+	if _, err := c.Fetch(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Fetch() error = %v, want %v while waiting Retry-After", err, context.DeadlineExceeded)
+	}
+	if got := srv.Hits("/api/"); got != 1 {
+		t.Errorf("hits = %d, want 1", got)
+	}
+}
+
+func TestCache(t *testing.T) {
+	srv := fpltest.NewServer(fpltest.NewSeason(1, 6, 4, 2))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		// first and second fetch of the same endpoint
+		first, second  fpltest.Faults
+		wantSecondHits int
+	}{
+		{
+			name:           "fresh body served from disk",
+			ttl:            time.Hour,
+			second:         fpltest.Faults{FailFirst: 10},
+			wantSecondHits: 0,
+		},
+		{
+			name: "stale body revalidated by etag",
+			ttl:  0,
+			// a 304 carries no body to truncate, the cached one is served
+			second:         fpltest.Faults{Truncate: true},
+			wantSecondHits: 1,
+		},
+		{
+			name:           "invalid body never cached",
+			ttl:            time.Hour,
+			first:          fpltest.Faults{Truncate: true},
+			wantSecondHits: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := client.GenericClient{
+				Endpoint: srv.BootstrapEndpoint(),
+				Retry:    testRetry,
+				Cache:    &client.Cache{Dir: t.TempDir(), DefaultTTL: tt.ttl},
+			}
+			srv.SetFaults(tt.first)
+			if _, err := c.Fetch(context.Background()); err != nil {
+				t.Fatalf("first Fetch() error = %v", err)
+			}
+			srv.SetFaults(tt.second)
+			bodyBytes, err := c.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("second Fetch() error = %v", err)
+			}
+			if !json.Valid(bodyBytes) {
+				t.Errorf("second Fetch() body is not valid json")
+			}
+			if got := srv.Hits("/api/"); got != tt.wantSecondHits {
+				t.Errorf("second Fetch() hits = %d, want %d", got, tt.wantSecondHits)
+			}
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := client.NewCache(t.TempDir())
+	c.DefaultTTL = time.Minute
+	tests := []struct {
+		url  string
+		want time.Duration
+	}{
+		{"https://fantasy.premierleague.com/api/bootstrap-static/", time.Hour},
+		{"https://fantasy.premierleague.com/api/element-summary/1/", 15 * time.Minute},
+		{"https://fantasy.premierleague.com/api/fixtures/", time.Minute},
+	}
+	for _, tt := range tests {
+		if got := c.TTL(tt.url); got != tt.want {
+			t.Errorf("TTL(%v) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestTape(t *testing.T) {
+	srv := fpltest.NewServer(fpltest.NewSeason(1, 6, 4, 2))
+	dir := t.TempDir()
+	tape, err := client.NewTape(dir, "v1", client.Record)
+	if err != nil {
+		t.Fatalf("NewTape(Record) error = %v", err)
+	}
+	recorded := map[string][]byte{}
+	for _, endpoint := range []string{srv.BootstrapEndpoint(), srv.FixturesEndpoint(), srv.URL + "/api/leagues-classic/1/standings/?page_standings=2"} {
+		c := client.GenericClient{Endpoint: endpoint, Retry: testRetry, Tape: tape}
+		bodyBytes, err := c.Fetch(context.Background())
+		var statusErr *client.StatusError
+		if errors.As(err, &statusErr) {
+			// nothing recorded for a failed fetch
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Fetch(%v) error = %v", endpoint, err)
+		}
+		recorded[endpoint[len(srv.URL):]] = bodyBytes
+	}
+	srv.Close()
+
+	// replay against another host, latest version
+	tape, err = client.NewTape(dir, "", client.Replay)
+	if err != nil {
+		t.Fatalf("NewTape(Replay) error = %v", err)
+	}
+	if tape.Version != "v1" {
+		t.Errorf("replayed version = %q, want v1", tape.Version)
+	}
+	tests := []struct {
+		path    string
+		wantErr error
+	}{
+		{path: "/api/bootstrap-static/"},
+		{path: "/api/fixtures/"},
+		{path: "/api/leagues-classic/1/standings/?page_standings=2", wantErr: client.ErrNotRecorded},
+		{path: "/api/element-summary/1/", wantErr: client.ErrNotRecorded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			c := client.GenericClient{Endpoint: "http://replay.invalid" + tt.path, Tape: tape}
+			bodyBytes, err := c.Fetch(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Fetch() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && string(bodyBytes) != string(recorded[tt.path]) {
+				t.Errorf("Fetch() replayed %d bytes, recorded %d", len(bodyBytes), len(recorded[tt.path]))
+			}
+		})
+	}
+
+	if _, err := client.NewTape(dir, "v2", client.Replay); err == nil {
+		t.Errorf("NewTape() of a missing version, want an error")
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TransportError when the request never produced a readable response
+type TransportError struct {
+	URL string
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("transport error on %v: %v", e.URL, e.Err)
+}
+
+// Unwrap to the underlying net/http error
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError when the response status code is not 2xx
+type StatusError struct {
+	URL        string
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %v on %v", e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// Temporary for status codes worth retrying (429, 5xx gateway/availability)
+func (e *StatusError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// DecodeError when the response body is not the expected json
+type DecodeError struct {
+	URL string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode error on %v: %v", e.URL, e.Err)
+}
+
+// Unwrap to the underlying encoding/json error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Mode of a Tape
+type Mode int
+
+// Record saves every fetched response, Replay serves them without network
+const (
+	Record Mode = iota + 1
+	Replay
+)
+
+// ErrNotRecorded when replaying a URL missing from the fixture directory
+var ErrNotRecorded = errors.New("response not recorded")
+
+// Tape records fetched responses into a versioned fixture directory, or replays them from it
+type Tape struct {
+	Dir     string
+	Version string
+	Mode    Mode
+}
+
+// Manifest stored at the root of every tape version
+type Manifest struct {
+	Version    string    `json:"version"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// NewTape in dir, version defaults to today's date on Record and to the latest version on Replay
+func NewTape(dir, version string, mode Mode) (*Tape, error) {
+	t := &Tape{Dir: dir, Version: version, Mode: mode}
+	switch mode {
+	case Record:
+		if t.Version == "" {
+			t.Version = time.Now().Format("2006-01-02")
+		}
+		if err := os.MkdirAll(t.root(), 0755); err != nil {
+			return nil, err
+		}
+		manifestBytes, err := json.MarshalIndent(Manifest{Version: t.Version, RecordedAt: time.Now()}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(filepath.Join(t.root(), "manifest.json"), manifestBytes, 0644); err != nil {
+			return nil, err
+		}
+	case Replay:
+		if t.Version == "" {
+			latest, err := latestVersion(dir)
+			if err != nil {
+				return nil, err
+			}
+			t.Version = latest
+		}
+		if _, err := os.Stat(filepath.Join(t.root(), "manifest.json")); err != nil {
+			return nil, fmt.Errorf("no tape version %q in %v: %w", t.Version, dir, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown tape mode %d", mode)
+	}
+	return t, nil
+}
+
+// load recorded body of rawURL
+func (t *Tape) load(rawURL string) ([]byte, error) {
+	path, err := t.path(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	bodyBytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %v", ErrNotRecorded, rawURL)
+	}
+	return bodyBytes, err
+}
+
+// save body of rawURL
+func (t *Tape) save(rawURL string, bodyBytes []byte) error {
+	path, err := t.path(rawURL)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, bodyBytes, 0644)
+}
+
+// path of the fixture for rawURL, independent of the host so replay works against any base URL
+func (t *Tape) path(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	name := strings.Trim(u.Path, "/")
+	if name == "" {
+		name = "index"
+	}
+	if u.RawQuery != "" {
+		name += "_" + url.PathEscape(u.RawQuery)
+	}
+	return filepath.Join(t.root(), filepath.FromSlash(name)+".json"), nil
+}
+
+// root directory of the current version
+func (t *Tape) root() string {
+	return filepath.Join(t.Dir, t.Version)
+}
+
+// latestVersion in dir, versions sort lexically (dates by default)
+func latestVersion(dir string) (string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	versions := []string{}
+	for _, info := range infos {
+		if info.IsDir() {
+			versions = append(versions, info.Name())
+		}
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no tape version found in %v", dir)
+	}
+	sort.Strings(versions)
+	return versions[len(versions)-1], nil
+}
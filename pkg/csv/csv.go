@@ -1,88 +1,237 @@
-// Package csv provides ability to store any StructSlice (bool|int|string) into csv
+// Package csv provides ability to store any StructSlice into csv, nested structs flattened
+// into columns named by their `csv:"name,omitempty"` tags
 package csv
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
-// StructSlice is automatically stored (bool|int|string) into csv (string)
-func StructSlice(myStructSlice interface{}, filePrefix string) {
-	// recover if myStructSlice is not valid
-	defer func() {
-		if err := recover(); err != nil {
-			fmt.Printf("recover from panic: %+v\n", err)
-			fmt.Printf("myStructSlice: %+v\n", myStructSlice)
-		}
-	}()
-	// generate emptyInstance of myStructSlice
-	emptyInstance := reflect.Zero(reflect.TypeOf(myStructSlice).Elem())
+// Dir of every csv file, none is written when empty
+var Dir = "csv_out"
+
+// FloatPrecision digits after the point of float columns, -1 for the fewest that
+// represent the value exactly
+var FloatPrecision = -1
+
+// TimeLayout of time.Time columns
+var TimeLayout = time.RFC3339
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// column of a struct field, nested fields reached through index one level at a time
+type column struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
 
-	// define file and writer
-	fileName := fmt.Sprintf("csv_out/%v-%v.csv", filePrefix, time.Now().Format("2006-01-02_15:00:00"))
+// StructSlice is stored into Dir/filePrefix-<time>.csv, one column per field of its
+// structs, see Write
+func StructSlice(myStructSlice interface{}, filePrefix string) error {
+	if Dir == "" {
+		return nil
+	}
+	fileName := filepath.Join(Dir, fmt.Sprintf("%v-%v.csv", filePrefix, time.Now().Format("2006-01-02_15:00:00")))
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
 	file, err := os.Create(fileName)
 	if err != nil {
-		log.Println("error os.Create():", err)
-		return
+		return err
+	}
+	if err := Write(file, myStructSlice); err != nil {
+		file.Close()
+		return fmt.Errorf("%v: %w", fileName, err)
 	}
-	defer file.Close()
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	return file.Close()
+}
 
-	// write headerNames based on emptyInstance
-	headerNames := []string{}
-	headerTypes := []reflect.Type{}
-	for i := 0; i < emptyInstance.NumField(); i++ {
-		headerNames = append(headerNames, emptyInstance.Type().Field(i).Name)
-		headerTypes = append(headerTypes, emptyInstance.Type().Field(i).Type)
-	}
-	err = writer.Write(headerNames)
+// Write myStructSlice, a slice of structs or of pointers to structs, as csv into w.
+// A field tagged `csv:"-"` is skipped, `csv:"name"` renames its column and omitempty
+// leaves the cell of a zero value empty. Nested structs are flattened into
+// "Parent.Child" columns, embedded ones without a prefix. Nil pointers and interfaces
+// are empty, time.Time follows TimeLayout, slices, maps and structs behind an
+// interface{} are json.
+func Write(w io.Writer, myStructSlice interface{}) error {
+	v := reflect.ValueOf(myStructSlice)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Errorf("csv rows must be a slice of structs, got %T", myStructSlice)
+	}
+	elemType := v.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv rows must be a slice of structs, got %T", myStructSlice)
+	}
+	cols, err := columns(elemType, "", nil, map[reflect.Type]bool{})
 	if err != nil {
-		log.Println("error writer.Write():", err)
-		return
+		return err
 	}
 
-	// appendEachRow should append row-by-row based on headerTypes
-	row := reflect.ValueOf(myStructSlice)
-	for i := 0; i < row.Len(); i++ {
-		appendEachRow(writer, headerTypes, row.Index(i).Interface())
+	writer := csv.NewWriter(w)
+	headerNames := make([]string, len(cols))
+	for i, c := range cols {
+		headerNames[i] = c.name
+	}
+	if err := writer.Write(headerNames); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		row, err := record(v.Index(i), cols)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", i, err)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
 	}
+	writer.Flush()
+	return writer.Error()
 }
 
-func appendEachRow(writer *csv.Writer, headerTypes []reflect.Type, row interface{}) {
-	boolType := reflect.TypeOf((bool)(false))
-	// floatType := reflect.TypeOf((float64)(0.0))
-	intType := reflect.TypeOf((int)(0))
-	stringType := reflect.TypeOf((string)(""))
+// columns of struct type t, names prefixed by prefix and indexes by index. A struct
+// already on path, the types being flattened, is a json column rather than a cycle.
+func columns(t reflect.Type, prefix string, index []int, path map[reflect.Type]bool) ([]column, error) {
+	path[t] = true
+	defer delete(path, t)
+	cols := []column{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name, omitEmpty := parseTag(f.Tag.Get("csv"))
+		if name == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		base := f.Type
+		for base.Kind() == reflect.Ptr {
+			base = base.Elem()
+		}
+		if base.Kind() == reflect.Struct && base != timeType && !path[base] {
+			nestedPrefix := prefix
+			switch {
+			case name != "":
+				nestedPrefix += name + "."
+			case !f.Anonymous:
+				nestedPrefix += f.Name + "."
+			}
+			nested, err := columns(base, nestedPrefix, fieldIndex, path)
+			if err != nil {
+				return nil, err
+			}
+			cols = append(cols, nested...)
+			continue
+		}
+		if f.PkgPath != "" {
+			// an embedded unexported non-struct type has nothing to export
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if err := supported(f.Type); err != nil {
+			return nil, fmt.Errorf("column %v: %w", prefix+name, err)
+		}
+		cols = append(cols, column{name: prefix + name, index: fieldIndex, omitEmpty: omitEmpty})
+	}
+	return cols, nil
+}
+
+// parseTag of csv into its name and omitempty option
+func parseTag(tag string) (name string, omitEmpty bool) {
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if strings.TrimSpace(opt) == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return strings.TrimSpace(parts[0]), omitEmpty
+}
+
+// supported when a value of t can be formatted into a cell
+func supported(t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return fmt.Errorf("unsupported type %v", t)
+	case reflect.Ptr:
+		return supported(t.Elem())
+	}
+	return nil
+}
 
-	// use Field(i) to detect column's type and append as string
-	allColumns := []string{}
-	v := reflect.ValueOf(row)
-	for i := 0; i < v.NumField(); i++ {
-		switch headerTypes[i] {
-		case boolType:
-			str := strconv.FormatBool(v.Field(i).Bool())
-			allColumns = append(allColumns, str)
-		// case floatType:
-		// 	str := strconv.FormatFloat(v.Field(i).Float(), 'f', 6, 64)
-		// 	allColumns = append(allColumns, str)
-		case intType:
-			str := strconv.FormatInt(v.Field(i).Int(), 10)
-			allColumns = append(allColumns, str)
-		case stringType:
-			allColumns = append(allColumns, v.Field(i).String())
-		default:
-			log.Panic("csv.StructSlice() only accept bool, float, int, or string. headerType:", headerTypes[i])
+// record of row's cols
+func record(row reflect.Value, cols []column) ([]string, error) {
+	allColumns := make([]string, len(cols))
+	for i, c := range cols {
+		v, ok := field(row, c.index)
+		if !ok || (c.omitEmpty && v.IsZero()) {
+			continue
+		}
+		str, err := format(v)
+		if err != nil {
+			return nil, fmt.Errorf("column %v: %w", c.name, err)
+		}
+		allColumns[i] = str
+	}
+	return allColumns, nil
+}
+
+// field of v at index, false behind a nil pointer
+func field(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+// format v as a cell
+func format(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+		return format(v.Elem())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', FloatPrecision, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', FloatPrecision, 64), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).Format(TimeLayout), nil
 		}
+	case reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return "", fmt.Errorf("unsupported type %v", v.Type())
 	}
-	err := writer.Write(allColumns)
+	jsonBytes, err := json.Marshal(v.Interface())
 	if err != nil {
-		log.Println("error writer.Write():", err)
-		return
+		return "", err
 	}
+	return string(jsonBytes), nil
 }
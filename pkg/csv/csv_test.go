@@ -0,0 +1,151 @@
+package csv_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+)
+
+type Inner struct {
+	A int
+	B string `csv:"bee,omitempty"`
+}
+
+type row struct {
+	Inner
+	ID     int
+	Skip   int `csv:"-"`
+	Nested *Inner
+	Named  Inner `csv:"n"`
+}
+
+type node struct {
+	ID   int
+	Next *node
+}
+
+type pair struct {
+	Left, Right node
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "flattened",
+			rows: []row{
+				{Inner: Inner{A: 1, B: "x"}, ID: 2, Skip: 3, Nested: &Inner{A: 4}, Named: Inner{A: 5, B: "y"}},
+				{ID: 6},
+			},
+			want: "A,bee,ID,Nested.A,Nested.bee,n.A,n.bee\n" +
+				"1,x,2,4,,5,y\n" +
+				"0,,6,,,0,\n",
+		},
+		{
+			name: "cycle as json",
+			rows: []*node{{ID: 1, Next: &node{ID: 2}}, {ID: 3}},
+			want: "ID,Next\n" +
+				`1,"{""ID"":2,""Next"":null}"` + "\n" +
+				"3,\n",
+		},
+		{
+			name: "same type twice without a cycle",
+			rows: []pair{{Left: node{ID: 1}, Right: node{ID: 2}}},
+			want: "Left.ID,Left.Next,Right.ID,Right.Next\n" +
+				"1,,2,\n",
+		},
+		{name: "not a slice", rows: row{}, wantErr: true},
+		{name: "not structs", rows: []int{1}, wantErr: true},
+		{name: "unsupported column", rows: []struct{ C chan int }{{}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			err := csv.Write(&b, tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && b.String() != tt.want {
+				t.Errorf("Write() =\n%v\nwant\n%v", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+type cells struct {
+	F     float64
+	F32   float32
+	T     time.Time
+	Any   interface{}
+	P     *int
+	Zero  int     `csv:",omitempty"`
+	ZeroF float64 `csv:"zf,omitempty"`
+	Skip  string  `csv:"-"`
+}
+
+func TestWriteCells(t *testing.T) {
+	at := time.Date(2026, 8, 15, 11, 30, 0, 0, time.UTC)
+	seven := 7
+	rows := []cells{
+		{F: 2.5, F32: 0.1, T: at, Any: map[string]int{"a": 1}, P: &seven, Zero: 3, ZeroF: 0.5, Skip: "x"},
+		{F: 1.0 / 3, Any: "text"},
+	}
+	tests := []struct {
+		name      string
+		precision int
+		layout    string
+		want      string
+	}{
+		{
+			name:      "shortest floats",
+			precision: -1,
+			layout:    time.RFC3339,
+			want: "F,F32,T,Any,P,Zero,zf\n" +
+				`2.5,0.1,2026-08-15T11:30:00Z,"{""a"":1}",7,3,0.5` + "\n" +
+				"0.3333333333333333,0,0001-01-01T00:00:00Z,text,,,\n",
+		},
+		{
+			name:      "fixed precision",
+			precision: 2,
+			layout:    "2006-01-02",
+			want: "F,F32,T,Any,P,Zero,zf\n" +
+				`2.50,0.10,2026-08-15,"{""a"":1}",7,3,0.50` + "\n" +
+				"0.33,0.00,0001-01-01,text,,,\n",
+		},
+	}
+	defer func() { csv.FloatPrecision, csv.TimeLayout = -1, time.RFC3339 }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csv.FloatPrecision, csv.TimeLayout = tt.precision, tt.layout
+			var b strings.Builder
+			if err := csv.Write(&b, rows); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Write() =\n%v\nwant\n%v", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestStructSlice(t *testing.T) {
+	csv.Dir = t.TempDir()
+	defer func() { csv.Dir = "csv_out" }()
+	if err := csv.StructSlice([]cells{{F: 1}}, "nested/cells"); err != nil {
+		t.Fatalf("StructSlice() error = %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(csv.Dir, "nested", "cells-[0-9]*.csv"))
+	if len(files) != 1 {
+		t.Errorf("StructSlice() files %v, want one", files)
+	}
+	if err := csv.StructSlice(map[int]int{}, "map"); err == nil {
+		t.Errorf("StructSlice() of a map, want an error")
+	}
+}
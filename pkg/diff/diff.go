@@ -0,0 +1,227 @@
+// Package diff provides the changes between two stored snapshots of bootstrap-static,
+// as a human readable report and as json
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+	"github.com/jadugnap/golang-fpl-101/pkg/storage"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// Player added to or removed from the game
+type Player struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Team    string `json:"team"`
+	NowCost int    `json:"now_cost"`
+}
+
+// TeamChange of a player moving club
+type TeamChange struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	OldTeam string `json:"old_team"`
+	NewTeam string `json:"new_team"`
+}
+
+// PriceMove of a player, in tenths of a million
+type PriceMove struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	OldCost int    `json:"old_cost"`
+	NewCost int    `json:"new_cost"`
+}
+
+// StatusChange of a player's availability status or news
+type StatusChange struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	OldStatus string `json:"old_status"`
+	NewStatus string `json:"new_status"`
+	OldNews   string `json:"old_news"`
+	NewNews   string `json:"new_news"`
+}
+
+// PointsDelta of a player's total points
+type PointsDelta struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	OldPoints int    `json:"old_points"`
+	NewPoints int    `json:"new_points"`
+	Delta     int    `json:"delta"`
+}
+
+// Report of the changes from Old to New, every list by player ID except
+// PointsDeltas, largest delta first
+type Report struct {
+	Old            string         `json:"old"`
+	New            string         `json:"new"`
+	AddedPlayers   []Player       `json:"added_players"`
+	RemovedPlayers []Player       `json:"removed_players"`
+	TeamChanges    []TeamChange   `json:"team_changes"`
+	PriceMoves     []PriceMove    `json:"price_moves"`
+	StatusChanges  []StatusChange `json:"status_changes"`
+	PointsDeltas   []PointsDelta  `json:"points_deltas"`
+}
+
+// Load a stored bootstrap-static snapshot from path: its json file, a tape version
+// directory recorded with -record, or a -storage sqlite database followed by the
+// run_at of one of its runs, e.g. "fpl.db@2026-08-01T10:00:00Z"
+func Load(path string) (fpl.Response, error) {
+	if i := strings.LastIndex(path, "@"); i > 0 {
+		return loadRun(path[:i], path[i+1:])
+	}
+	res := fpl.Response{}
+	info, err := os.Stat(path)
+	if err != nil {
+		return res, err
+	}
+	if info.IsDir() {
+		u, err := url.Parse(fpl.Endpoint)
+		if err != nil {
+			return res, err
+		}
+		path = filepath.Join(path, filepath.FromSlash(strings.Trim(u.Path, "/"))+".json")
+	}
+	bodyBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return res, err
+	}
+	if err := json.Unmarshal(bodyBytes, &res); err != nil {
+		return res, fmt.Errorf("bootstrap-static %v: %w", path, err)
+	}
+	return res, nil
+}
+
+// loadRun of the players and teams saved by the run at runAt into the sqlite database at path
+func loadRun(path, runAt string) (fpl.Response, error) {
+	res := fpl.Response{}
+	if err := storage.LoadSQLite(path, "players", runAt, &res.Players); err != nil {
+		return res, err
+	}
+	if err := storage.LoadSQLite(path, "teams", runAt, &res.Teams); err != nil {
+		return res, err
+	}
+	if len(res.Players) == 0 {
+		return res, fmt.Errorf("no players saved at %v in %v", runAt, path)
+	}
+	return res, nil
+}
+
+// Compare oldRes to newRes, labelled oldName and newName in the Report
+func Compare(oldName string, oldRes fpl.Response, newName string, newRes fpl.Response) Report {
+	oldRegistry := registry.New(oldRes.Players, oldRes.Teams, oldRes.PlayerRoles)
+	newRegistry := registry.New(newRes.Players, newRes.Teams, newRes.PlayerRoles)
+	r := Report{
+		Old:            oldName,
+		New:            newName,
+		AddedPlayers:   []Player{},
+		RemovedPlayers: []Player{},
+		TeamChanges:    []TeamChange{},
+		PriceMoves:     []PriceMove{},
+		StatusChanges:  []StatusChange{},
+		PointsDeltas:   []PointsDelta{},
+	}
+	id2Old := make(map[int]team.Player, len(oldRes.Players))
+	for _, p := range oldRes.Players {
+		id2Old[p.ID] = p
+	}
+	id2New := make(map[int]team.Player, len(newRes.Players))
+	for _, p := range newRes.Players {
+		id2New[p.ID] = p
+	}
+
+	for _, p := range sortedByID(oldRes.Players) {
+		if _, ok := id2New[p.ID]; !ok {
+			r.RemovedPlayers = append(r.RemovedPlayers, Player{ID: p.ID, Name: p.WebName, Team: oldRegistry.TeamName(p.TeamID), NowCost: p.NowCost})
+		}
+	}
+	for _, p := range sortedByID(newRes.Players) {
+		old, ok := id2Old[p.ID]
+		if !ok {
+			r.AddedPlayers = append(r.AddedPlayers, Player{ID: p.ID, Name: p.WebName, Team: newRegistry.TeamName(p.TeamID), NowCost: p.NowCost})
+			continue
+		}
+		if old.TeamID != p.TeamID {
+			r.TeamChanges = append(r.TeamChanges, TeamChange{ID: p.ID, Name: p.WebName, OldTeam: oldRegistry.TeamName(old.TeamID), NewTeam: newRegistry.TeamName(p.TeamID)})
+		}
+		if old.NowCost != p.NowCost {
+			r.PriceMoves = append(r.PriceMoves, PriceMove{ID: p.ID, Name: p.WebName, OldCost: old.NowCost, NewCost: p.NowCost})
+		}
+		if old.Status != p.Status || old.News != p.News {
+			r.StatusChanges = append(r.StatusChanges, StatusChange{ID: p.ID, Name: p.WebName, OldStatus: old.Status, NewStatus: p.Status, OldNews: old.News, NewNews: p.News})
+		}
+		if old.TotalPoints != p.TotalPoints {
+			r.PointsDeltas = append(r.PointsDeltas, PointsDelta{ID: p.ID, Name: p.WebName, OldPoints: old.TotalPoints, NewPoints: p.TotalPoints, Delta: p.TotalPoints - old.TotalPoints})
+		}
+	}
+	sort.SliceStable(r.PointsDeltas, func(i, j int) bool { return r.PointsDeltas[i].Delta > r.PointsDeltas[j].Delta })
+	return r
+}
+
+// sortedByID copy of players
+func sortedByID(players []team.Player) []team.Player {
+	sorted := append([]team.Player{}, players...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+// WriteJSON of r into w, indented
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText of r into w, one section per kind of change and one line per player
+func (r Report) WriteText(w io.Writer) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "bootstrap-static diff %v -> %v\n", r.Old, r.New)
+
+	fmt.Fprintf(b, "\nAdded players (%d)\n", len(r.AddedPlayers))
+	for _, p := range r.AddedPlayers {
+		fmt.Fprintf(b, "  + %v (%v) £%v\n", p.Name, p.Team, cost(p.NowCost))
+	}
+	fmt.Fprintf(b, "\nRemoved players (%d)\n", len(r.RemovedPlayers))
+	for _, p := range r.RemovedPlayers {
+		fmt.Fprintf(b, "  - %v (%v) £%v\n", p.Name, p.Team, cost(p.NowCost))
+	}
+	fmt.Fprintf(b, "\nTeam changes (%d)\n", len(r.TeamChanges))
+	for _, c := range r.TeamChanges {
+		fmt.Fprintf(b, "  %v: %v -> %v\n", c.Name, c.OldTeam, c.NewTeam)
+	}
+	fmt.Fprintf(b, "\nPrice moves (%d)\n", len(r.PriceMoves))
+	for _, m := range r.PriceMoves {
+		fmt.Fprintf(b, "  %v: £%v -> £%v (%+.1f)\n", m.Name, cost(m.OldCost), cost(m.NewCost), float64(m.NewCost-m.OldCost)/10)
+	}
+	fmt.Fprintf(b, "\nStatus/news changes (%d)\n", len(r.StatusChanges))
+	for _, c := range r.StatusChanges {
+		fmt.Fprintf(b, "  %v: %v -> %v", c.Name, c.OldStatus, c.NewStatus)
+		if c.NewNews != c.OldNews {
+			fmt.Fprintf(b, " %q", c.NewNews)
+		}
+		fmt.Fprintln(b)
+	}
+	fmt.Fprintf(b, "\nPoints deltas (%d)\n", len(r.PointsDeltas))
+	for _, d := range r.PointsDeltas {
+		fmt.Fprintf(b, "  %v: %d -> %d (%+d)\n", d.Name, d.OldPoints, d.NewPoints, d.Delta)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// cost in millions of a now_cost
+func cost(nowCost int) string {
+	return fmt.Sprintf("%.1fm", float64(nowCost)/10)
+}
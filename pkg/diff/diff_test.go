@@ -0,0 +1,90 @@
+package diff_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/diff"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+	"github.com/jadugnap/golang-fpl-101/pkg/storage"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+var teams = []team.Team{{ID: 1, ShortName: "ARS"}, {ID: 2, ShortName: "CHE"}}
+
+func player(id, teamID, nowCost, totalPoints int, status, news string) team.Player {
+	return team.Player{ID: id, WebName: string(rune('a' + id)), TeamID: teamID, NowCost: nowCost, TotalPoints: totalPoints, Status: status, News: news}
+}
+
+func TestCompare(t *testing.T) {
+	oldRes := fpl.Response{Teams: teams, Players: []team.Player{
+		player(1, 1, 50, 10, "a", ""),
+		player(2, 1, 60, 20, "a", ""),
+		player(3, 2, 70, 30, "a", ""),
+		player(4, 2, 80, 40, "a", ""),
+	}}
+	newRes := fpl.Response{Teams: teams, Players: []team.Player{
+		// moved club
+		player(1, 2, 50, 10, "a", ""),
+		// price rise and points
+		player(2, 1, 61, 22, "a", ""),
+		// injured, more points
+		player(3, 2, 70, 39, "i", "Knee injury"),
+		// player 4 removed, player 5 added
+		player(5, 1, 45, 0, "a", ""),
+	}}
+
+	got := diff.Compare("old", oldRes, "new", newRes)
+	want := diff.Report{
+		Old:            "old",
+		New:            "new",
+		AddedPlayers:   []diff.Player{{ID: 5, Name: "f", Team: "ARS", NowCost: 45}},
+		RemovedPlayers: []diff.Player{{ID: 4, Name: "e", Team: "CHE", NowCost: 80}},
+		TeamChanges:    []diff.TeamChange{{ID: 1, Name: "b", OldTeam: "ARS", NewTeam: "CHE"}},
+		PriceMoves:     []diff.PriceMove{{ID: 2, Name: "c", OldCost: 60, NewCost: 61}},
+		StatusChanges:  []diff.StatusChange{{ID: 3, Name: "d", OldStatus: "a", NewStatus: "i", NewNews: "Knee injury"}},
+		// largest delta first
+		PointsDeltas: []diff.PointsDelta{
+			{ID: 3, Name: "d", OldPoints: 30, NewPoints: 39, Delta: 9},
+			{ID: 2, Name: "c", OldPoints: 20, NewPoints: 22, Delta: 2},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	same := diff.Compare("old", oldRes, "old", oldRes)
+	if len(same.AddedPlayers)+len(same.RemovedPlayers)+len(same.TeamChanges)+len(same.PriceMoves)+len(same.StatusChanges)+len(same.PointsDeltas) != 0 {
+		t.Errorf("Compare() of a snapshot to itself = %+v, want no change", same)
+	}
+}
+
+func TestLoadSQLiteRun(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "fpl.db")
+	runAt := time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC)
+	store, err := storage.OpenSQLite(dbPath, runAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players := []team.Player{player(1, 1, 50, 10, "d", "75% chance of playing")}
+	if err := store.Save("players", players); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("teams", teams); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	res, err := diff.Load(dbPath + "@" + runAt.Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(res.Players, players) || !reflect.DeepEqual(res.Teams, teams) {
+		t.Errorf("Load() = %+v and %+v, want %+v and %+v", res.Players, res.Teams, players, teams)
+	}
+	if _, err := diff.Load(dbPath + "@2026-08-02T10:00:00Z"); err == nil {
+		t.Errorf("Load() of a run not saved, want an error")
+	}
+}
@@ -2,15 +2,17 @@
 package element
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/jadugnap/golang-fpl-101/pkg/aggregate"
 	"github.com/jadugnap/golang-fpl-101/pkg/client"
 	"github.com/jadugnap/golang-fpl-101/pkg/csv"
-	"github.com/jadugnap/golang-fpl-101/proto/pb"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
 )
 
 // RawEndpoint to get element-summary response
@@ -18,12 +20,20 @@ var (
 	RawEndpoint = "https://fantasy.premierleague.com/api/element-summary/%d/"
 )
 
+// DefaultConcurrency of element-summary requests when Element.Concurrency is not set
+const DefaultConcurrency = 8
+
 // Element for element-summary information
 type Element struct {
 	Client         client.GenericClient
+	Registry       *registry.Registry
 	PlayerIDlist   []int
+	FailedIDlist   []int
+	Concurrency    int
+	Progress       func(done, failed, total int)
 	Res            SummaryResponse
 	HistoryList    []History
+	ID2History     map[int][]History
 	Team2Gw2Points map[string]map[int]int
 	PlayerID       int
 	PlayerName     string
@@ -76,14 +86,15 @@ type History struct {
 	TeamHScore int  `json:"team_h_score"`
 	TeamAScore int  `json:"team_a_score"`
 
-	// Bps              int       `json:"bps"`
-	// Creativity       string    `json:"creativity"`
-	// Fixture          int       `json:"fixture"`
-	// IctIndex         string    `json:"ict_index"`
-	// Influence        string    `json:"influence"`
+	Fixture    int    `json:"fixture"`
+	Bps        int    `json:"bps"`
+	Influence  string `json:"influence"`
+	Creativity string `json:"creativity"`
+	Threat     string `json:"threat"`
+	IctIndex   string `json:"ict_index"`
+
 	// KickoffTime      time.Time `json:"kickoff_time"`
 	// Selected         int       `json:"selected"`
-	// Threat           string    `json:"threat"`
 	// TransfersBalance int       `json:"transfers_balance"`
 	// TransfersIn      int       `json:"transfers_in"`
 	// TransfersOut     int       `json:"transfers_out"`
@@ -131,91 +142,168 @@ type PastYear struct {
 	TotalPoints int `json:"total_points"`
 }
 
-// GetElementSummaryToCsv from api/element-summary/
-func (e *Element) GetElementSummaryToCsv() {
+// GetElementSummaryToCsv from api/element-summary/, players failing to fetch or left
+// unsent once ctx is done are in FailedIDlist rather than returned
+func (e *Element) GetElementSummaryToCsv(ctx context.Context) error {
 	start := time.Now()
 	defer func() {
 		log.Printf("Took %v to GetResponse from %v\n", time.Since(start), e.Client.Endpoint[:len(e.Client.Endpoint)-3])
 	}()
 
-	historyQueue := make(chan []History, len(e.PlayerIDlist))
-	// use WaitGroup to getResponse concurrently
-	var wg sync.WaitGroup
-	for _, pID := range e.PlayerIDlist {
-		// filter out entries from (f *FPL) addSummaryRow()
-		if pID > 1000 {
-			continue
-		}
+	playerIDs := e.PlayerIDlist
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	progress := e.Progress
+	if progress == nil {
+		progress = logProgress
+	}
 
-		// start WaitGroup counter & the goroutine
+	pIDQueue := make(chan int)
+	historyQueue := make(chan []History, len(playerIDs))
+	failedQueue := make(chan int, len(playerIDs))
+	var mu sync.Mutex
+	done, failed := 0, 0
+	// use WaitGroup to bound the goroutines into a worker pool
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
 		wg.Add(1)
-		go func(pID int, wg *sync.WaitGroup) {
+		go func(wg *sync.WaitGroup) {
 			defer wg.Done()
+			for pID := range pIDQueue {
+				historyList, err := e.getElementSummaryToCsv(ctx, pID)
+				if err != nil {
+					log.Printf("error on playerID: %+v, %+v\n", pID, err)
+					failedQueue <- pID
+				} else {
+					historyQueue <- historyList
+				}
 
-			// new instance for each local Element in each goroutine
-			localE := Element{
-				Client:   e.Client,
-				PlayerID: pID,
+				mu.Lock()
+				done++
+				if err != nil {
+					failed++
+				}
+				progress(done, failed, len(playerIDs))
+				mu.Unlock()
 			}
-			localE.Client.Endpoint = fmt.Sprintf(e.Client.Endpoint, pID)
-			bodyBytes := localE.Client.GetResponse()
-
-			// define and use SummaryResponse here, no need to return
-			localE.Res = SummaryResponse{}
-			if err := json.Unmarshal(bodyBytes, &localE.Res); err != nil {
-				log.Printf("error json.Unmarshal(): %+v\n", err)
-				log.Printf("error on playerID: %+v\n", pID)
-				return // from go func()
-			}
-			localE.fillFixturesHistoryPerPlayer()
-			historyQueue <- localE.Res.PastMatches
-
-			// store element-summary into csv
-			fixturePrefix := fmt.Sprintf("fpl-players/individual/fixtures/%+v-%+v-%+v", localE.Team, localE.PlayerName, pID)
-			matchPrefix := fmt.Sprintf("fpl-players/individual/pastmatches/%+v-%+v-%+v", localE.Team, localE.PlayerName, pID)
-			yearPrefix := fmt.Sprintf("fpl-players/individual/pastyears/%+v-%+v-%+v", localE.Team, localE.PlayerName, pID)
-			csv.StructSlice(localE.Res.Fixtures, fixturePrefix)
-			csv.StructSlice(localE.Res.PastMatches, matchPrefix)
-			csv.StructSlice(localE.Res.PastYears, yearPrefix)
-		}(pID, &wg)
+		}(&wg)
+	}
+	for _, pID := range playerIDs {
+		select {
+		case pIDQueue <- pID:
+		case <-ctx.Done():
+			failedQueue <- pID
+		}
 	}
+	close(pIDQueue)
 	wg.Wait()
 	close(historyQueue)
+	close(failedQueue)
+
+	e.FailedIDlist = nil
+	for pID := range failedQueue {
+		e.FailedIDlist = append(e.FailedIDlist, pID)
+	}
+	sort.Ints(e.FailedIDlist)
+	if len(e.FailedIDlist) > 0 {
+		log.Printf("%d of %d element-summary failed: %+v\n", len(e.FailedIDlist), len(playerIDs), e.FailedIDlist)
+	}
 
 	e.Team2Gw2Points = make(map[string]map[int]int)
+	e.ID2History = make(map[int][]History)
+	allHistory := []History{}
 	for historyList := range historyQueue {
 		e.HistoryList = historyList
 		e.fillOpponentPoints()
+		for _, h := range historyList {
+			e.ID2History[h.PlayerID] = append(e.ID2History[h.PlayerID], h)
+		}
+		allHistory = append(allHistory, historyList...)
 	}
 	// fmt.Printf("e.Team2Gw2Points: %+v\n", e.Team2Gw2Points)
+
+	metrics, err := aggregate.Aggregator{
+		GroupBy: aggregate.ByTeamPosition,
+		Metrics: Metrics,
+		Position: func(row interface{}) string {
+			return e.Registry.PlayerPosition(row.(History).PlayerID)
+		},
+	}.Compute(allHistory)
+	if err != nil {
+		return err
+	}
+	return csv.StructSlice(aggregate.Rows(metrics), "fpl-players/history-metrics")
+}
+
+// getElementSummaryToCsv for a single player
+// output: past matches to accumulate opponent points
+func (e *Element) getElementSummaryToCsv(ctx context.Context, pID int) ([]History, error) {
+	// new instance for each local Element in each goroutine
+	localE := Element{
+		Client:   e.Client,
+		Registry: e.Registry,
+		PlayerID: pID,
+	}
+	localE.Client.Endpoint = fmt.Sprintf(e.Client.Endpoint, pID)
+
+	// define and use SummaryResponse here, no need to return
+	localE.Res = SummaryResponse{}
+	if err := localE.Client.FetchJSON(ctx, &localE.Res); err != nil {
+		return nil, err
+	}
+	localE.fillFixturesHistoryPerPlayer()
+
+	// store element-summary into csv
+	fixturePrefix := fmt.Sprintf("fpl-players/individual/fixtures/%+v-%+v-%+v", localE.Team, localE.PlayerName, pID)
+	matchPrefix := fmt.Sprintf("fpl-players/individual/pastmatches/%+v-%+v-%+v", localE.Team, localE.PlayerName, pID)
+	yearPrefix := fmt.Sprintf("fpl-players/individual/pastyears/%+v-%+v-%+v", localE.Team, localE.PlayerName, pID)
+	if err := csv.StructSlice(localE.Res.Fixtures, fixturePrefix); err != nil {
+		return nil, err
+	}
+	if err := csv.StructSlice(localE.Res.PastMatches, matchPrefix); err != nil {
+		return nil, err
+	}
+	if err := csv.StructSlice(localE.Res.PastYears, yearPrefix); err != nil {
+		return nil, err
+	}
+	return localE.Res.PastMatches, nil
+}
+
+// logProgress every 50 players and on the last one
+func logProgress(done, failed, total int) {
+	if done%50 == 0 || done == total {
+		log.Printf("element-summary progress: %d/%d done, %d failed\n", done, total, failed)
+	}
 }
 
 // fillFixturesHistoryPerPlayer from teams to element summary
 func (e *Element) fillFixturesHistoryPerPlayer() {
-	e.PlayerName = pb.Player_Webname_name[int32(e.PlayerID)]
+	e.PlayerName = e.Registry.PlayerName(e.PlayerID)
 	e.Team = "na"
 	if len(e.Res.Fixtures) == 0 {
 		fmt.Printf("No fixture found for playerName: %+v.\n", e.PlayerName)
 	} else if e.Res.Fixtures[0].IsHome {
-		e.Team = pb.Team_Shortname_name[int32(e.Res.Fixtures[0].TeamH)]
+		e.Team = e.Registry.TeamName(e.Res.Fixtures[0].TeamH)
 	} else {
-		e.Team = pb.Team_Shortname_name[int32(e.Res.Fixtures[0].TeamA)]
+		e.Team = e.Registry.TeamName(e.Res.Fixtures[0].TeamA)
 	}
 
 	for i, f := range e.Res.Fixtures {
 		e.Res.Fixtures[i].PlayerName = e.PlayerName
 		e.Res.Fixtures[i].Team = e.Team
 		if e.Res.Fixtures[i].IsHome {
-			e.Res.Fixtures[i].Opponent = pb.Team_Shortname_name[int32(f.TeamA)]
+			e.Res.Fixtures[i].Opponent = e.Registry.TeamName(f.TeamA)
 		} else {
-			e.Res.Fixtures[i].Opponent = pb.Team_Shortname_name[int32(f.TeamH)]
+			e.Res.Fixtures[i].Opponent = e.Registry.TeamName(f.TeamH)
 		}
 	}
 
 	for i, h := range e.Res.PastMatches {
 		e.Res.PastMatches[i].PlayerName = e.PlayerName
 		e.Res.PastMatches[i].Team = e.Team
-		opp := pb.Team_Shortname_name[int32(h.OpponentID)]
+		opp := e.Registry.TeamName(h.OpponentID)
 		e.Res.PastMatches[i].Opponent = opp
 	}
 
@@ -231,7 +319,7 @@ func (e *Element) fillFixturesHistoryPerPlayer() {
 // fillOpponentPoints from element summary to a map
 func (e *Element) fillOpponentPoints() {
 	for i, h := range e.HistoryList {
-		opp := pb.Team_Shortname_name[int32(h.OpponentID)]
+		opp := e.Registry.TeamName(h.OpponentID)
 
 		// check whether inner map for opposing team "opp" exists
 		if _, ok := e.Team2Gw2Points[opp]; !ok {
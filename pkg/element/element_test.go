@@ -0,0 +1,120 @@
+package element_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpltest"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+)
+
+func TestGetElementSummaryToCsv(t *testing.T) {
+	season := fpltest.NewSeason(1, 6, 4, 2)
+	srv := fpltest.NewServer(season)
+	defer srv.Close()
+	res := season.Bootstrap
+	r := registry.New(res.Players, res.Teams, res.PlayerRoles)
+
+	tests := []struct {
+		name       string
+		ids        []int
+		faults     fpltest.Faults
+		wantFailed []int
+	}{
+		{
+			name: "every player",
+			ids:  []int{1, 2, 3, 16, 17, 90},
+		},
+		{
+			name:       "missing player",
+			ids:        []int{1, 2, 91},
+			wantFailed: []int{91},
+		},
+		{
+			name:       "failing player",
+			ids:        []int{1, 2, 3},
+			faults:     fpltest.Faults{Paths: []string{"/element-summary/2/"}, FailFirst: 10, FailStatus: http.StatusServiceUnavailable},
+			wantFailed: []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csv.Dir = t.TempDir()
+			defer func() { csv.Dir = "csv_out" }()
+			srv.SetFaults(tt.faults)
+
+			progressCalls := 0
+			e := element.Element{
+				Client: client.GenericClient{
+					Endpoint: srv.ElementSummaryEndpoint(),
+					Retry:    client.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+				},
+				Registry:     r,
+				PlayerIDlist: tt.ids,
+				Concurrency:  2,
+				Progress:     func(done, failed, total int) { progressCalls++ },
+			}
+			if err := e.GetElementSummaryToCsv(context.Background()); err != nil {
+				t.Fatalf("GetElementSummaryToCsv() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(e.FailedIDlist, tt.wantFailed) {
+				t.Errorf("FailedIDlist = %v, want %v", e.FailedIDlist, tt.wantFailed)
+			}
+			if progressCalls != len(tt.ids) {
+				t.Errorf("Progress called %d times, want %d", progressCalls, len(tt.ids))
+			}
+			wantFetched := len(tt.ids) - len(tt.wantFailed)
+			if len(e.ID2History) != wantFetched {
+				t.Errorf("ID2History of %d players, want %d", len(e.ID2History), wantFetched)
+			}
+			for pID, historyList := range e.ID2History {
+				// 2 gameweeks played, one match each
+				if len(historyList) != 2 {
+					t.Errorf("player %d has %d past matches, want 2", pID, len(historyList))
+				}
+			}
+			for _, dir := range []string{"fixtures", "pastmatches", "pastyears"} {
+				files, _ := filepath.Glob(filepath.Join(csv.Dir, "fpl-players/individual", dir, "*.csv"))
+				if len(files) != wantFetched {
+					t.Errorf("%d %v files, want %d", len(files), dir, wantFetched)
+				}
+			}
+			if files, _ := filepath.Glob(filepath.Join(csv.Dir, "fpl-players/history-metrics-*.csv")); len(files) != 1 {
+				t.Errorf("%d history-metrics files, want 1", len(files))
+			}
+		})
+	}
+}
+
+func TestGetElementSummaryToCsvCancelled(t *testing.T) {
+	csv.Dir = t.TempDir()
+	defer func() { csv.Dir = "csv_out" }()
+	season := fpltest.NewSeason(1, 6, 4, 2)
+	srv := fpltest.NewServer(season)
+	defer srv.Close()
+	res := season.Bootstrap
+	e := element.Element{
+		Client:       client.GenericClient{Endpoint: srv.ElementSummaryEndpoint()},
+		Registry:     registry.New(res.Players, res.Teams, res.PlayerRoles),
+		PlayerIDlist: []int{3, 1, 2},
+		Concurrency:  1,
+		Progress:     func(done, failed, total int) {},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := e.GetElementSummaryToCsv(ctx); err != nil {
+		t.Fatalf("GetElementSummaryToCsv() error = %v", err)
+	}
+	// whether sent or not, no summary comes back
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(e.FailedIDlist, want) {
+		t.Errorf("FailedIDlist = %v, want %v", e.FailedIDlist, want)
+	}
+}
@@ -0,0 +1,14 @@
+package element
+
+import "github.com/jadugnap/golang-fpl-101/pkg/aggregate"
+
+// Metrics of History rows, the aggregate.Common ones and History only ones.
+// Register more on it to have them in history-metrics.
+var Metrics = aggregate.MustNewRegistry(append(aggregate.Common(),
+	aggregate.Metric{Name: "goals_per_90", Field: "GoalsScored", Reducer: aggregate.Per90},
+	aggregate.Metric{Name: "assists_per_90", Field: "Assists", Reducer: aggregate.Per90},
+	aggregate.Metric{Name: "bonus_per_90", Field: "Bonus", Reducer: aggregate.Per90},
+	aggregate.Metric{Name: "clean_sheets", Field: "CleanSheets", Reducer: aggregate.Sum},
+	aggregate.Metric{Name: "saves_per_90", Field: "Saves", Reducer: aggregate.Per90},
+	aggregate.Metric{Name: "goals_conceded", Field: "GoalsConceded", Reducer: aggregate.Sum},
+)...)
@@ -0,0 +1,376 @@
+// Package entry provides structures and methods to manage a manager's api/entry/{id}
+package entry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// RawEndpoint to get entry response, history/, transfers/ and event/{gw}/picks/ are relative to it
+var (
+	RawEndpoint = "https://fantasy.premierleague.com/api/entry/%d/"
+)
+
+// Chip names as in active_chip and history chips
+const (
+	Wildcard      = "wildcard"
+	FreeHit       = "freehit"
+	BenchBoost    = "bboost"
+	TripleCaptain = "3xc"
+)
+
+// Entry for a manager's information
+type Entry struct {
+	Client     client.GenericClient
+	ID         int
+	Players    []team.Player
+	ID2History map[int][]element.History
+	Res        Response
+	History    HistoryResponse
+	Gw2Picks   map[int]PicksResponse
+	Transfers  []Transfer
+}
+
+// Response from api/entry/{id}/
+type Response struct {
+	ID                         int    `json:"id"`
+	Name                       string `json:"name"`
+	PlayerFirstName            string `json:"player_first_name"`
+	PlayerLastName             string `json:"player_last_name"`
+	StartedEvent               int    `json:"started_event"`
+	CurrentEvent               int    `json:"current_event"`
+	FavouriteTeam              int    `json:"favourite_team"`
+	SummaryOverallPoints       int    `json:"summary_overall_points"`
+	SummaryOverallRank         int    `json:"summary_overall_rank"`
+	SummaryEventPoints         int    `json:"summary_event_points"`
+	SummaryEventRank           int    `json:"summary_event_rank"`
+	LastDeadlineBank           int    `json:"last_deadline_bank"`
+	LastDeadlineValue          int    `json:"last_deadline_value"`
+	LastDeadlineTotalTransfers int    `json:"last_deadline_total_transfers"`
+}
+
+// HistoryResponse from api/entry/{id}/history/
+type HistoryResponse struct {
+	Current []GameweekHistory `json:"current"`
+	Past    []PastSeason      `json:"past"`
+	Chips   []Chip            `json:"chips"`
+}
+
+// GameweekHistory ... to skip go-lint
+type GameweekHistory struct {
+	Event              int `json:"event"`
+	Points             int `json:"points"`
+	TotalPoints        int `json:"total_points"`
+	Rank               int `json:"rank"`
+	RankSort           int `json:"rank_sort"`
+	OverallRank        int `json:"overall_rank"`
+	Bank               int `json:"bank"`
+	Value              int `json:"value"`
+	EventTransfers     int `json:"event_transfers"`
+	EventTransfersCost int `json:"event_transfers_cost"`
+	PointsOnBench      int `json:"points_on_bench"`
+}
+
+// PastSeason ... to skip go-lint
+type PastSeason struct {
+	SeasonName  string `json:"season_name"`
+	TotalPoints int    `json:"total_points"`
+	Rank        int    `json:"rank"`
+}
+
+// Chip ... to skip go-lint
+type Chip struct {
+	Name  string    `json:"name"`
+	Time  time.Time `json:"time"`
+	Event int       `json:"event"`
+}
+
+// Transfer from api/entry/{id}/transfers/, costs are the prices paid and received
+type Transfer struct {
+	ElementIn      int       `json:"element_in"`
+	ElementInCost  int       `json:"element_in_cost"`
+	ElementOut     int       `json:"element_out"`
+	ElementOutCost int       `json:"element_out_cost"`
+	Entry          int       `json:"entry"`
+	Event          int       `json:"event"`
+	Time           time.Time `json:"time"`
+}
+
+// PicksResponse from api/entry/{id}/event/{gw}/picks/
+type PicksResponse struct {
+	ActiveChip    string          `json:"active_chip"`
+	AutomaticSubs []AutomaticSub  `json:"automatic_subs"`
+	EntryHistory  GameweekHistory `json:"entry_history"`
+	Picks         []Pick          `json:"picks"`
+}
+
+// AutomaticSub ... to skip go-lint
+type AutomaticSub struct {
+	Entry      int `json:"entry"`
+	ElementIn  int `json:"element_in"`
+	ElementOut int `json:"element_out"`
+	Event      int `json:"event"`
+}
+
+// Pick ... to skip go-lint
+type Pick struct {
+	Element       int  `json:"element"`
+	Position      int  `json:"position"`
+	Multiplier    int  `json:"multiplier"`
+	IsCaptain     bool `json:"is_captain"`
+	IsViceCaptain bool `json:"is_vice_captain"`
+}
+
+// PickRow of a Pick joined to team.Player for csv.StructSlice
+type PickRow struct {
+	Entry         int
+	Gameweek      int
+	Position      int
+	PlayerID      int
+	WebName       string
+	TeamName      string
+	RoleName      string
+	Multiplier    int
+	IsCaptain     bool
+	IsViceCaptain bool
+	NowCost       int
+	GwPoints      int
+	PickPoints    int
+	TotalPoints   int
+	ActiveChip    string
+}
+
+// GameweekRow of an entry's gameweek for csv.StructSlice
+type GameweekRow struct {
+	Entry              int
+	Gameweek           int
+	Points             int
+	TotalPoints        int
+	Rank               int
+	OverallRank        int
+	Bank               int
+	Value              int
+	EventTransfers     int
+	EventTransfersCost int
+	PointsOnBench      int
+	ActiveChip         string
+	Captain            string
+	ViceCaptain        string
+}
+
+// GetEntryToCsv with history and picks of gameweeks, every played gameweek when empty
+func (e *Entry) GetEntryToCsv(ctx context.Context, gameweeks []int) error {
+	start := time.Now()
+	defer func() {
+		log.Printf("Took %v to GetResponse from %v\n", time.Since(start), fmt.Sprintf(e.Client.Endpoint, e.ID))
+	}()
+
+	if err := e.FetchEntry(ctx); err != nil {
+		return err
+	}
+	if err := e.FetchHistory(ctx); err != nil {
+		return err
+	}
+	if len(gameweeks) == 0 {
+		for _, gw := range e.History.Current {
+			gameweeks = append(gameweeks, gw.Event)
+		}
+	}
+	for _, gw := range gameweeks {
+		if _, err := e.FetchPicks(ctx, gw); err != nil {
+			return err
+		}
+	}
+	return e.ToCsv()
+}
+
+// FetchEntry from api/entry/{id}/ into Res
+func (e *Entry) FetchEntry(ctx context.Context) error {
+	return e.fetch(ctx, "", &e.Res)
+}
+
+// FetchHistory from api/entry/{id}/history/ into History
+func (e *Entry) FetchHistory(ctx context.Context) error {
+	return e.fetch(ctx, "history/", &e.History)
+}
+
+// FetchPicks from api/entry/{id}/event/{gw}/picks/ into Gw2Picks
+func (e *Entry) FetchPicks(ctx context.Context, gw int) (PicksResponse, error) {
+	picks := PicksResponse{}
+	if err := e.fetch(ctx, fmt.Sprintf("event/%d/picks/", gw), &picks); err != nil {
+		return picks, err
+	}
+	if e.Gw2Picks == nil {
+		e.Gw2Picks = make(map[int]PicksResponse)
+	}
+	e.Gw2Picks[gw] = picks
+	return picks, nil
+}
+
+// FetchTransfers from api/entry/{id}/transfers/ into Transfers
+func (e *Entry) FetchTransfers(ctx context.Context) error {
+	return e.fetch(ctx, "transfers/", &e.Transfers)
+}
+
+// fetch path relative to api/entry/{id}/ into v
+func (e *Entry) fetch(ctx context.Context, path string, v interface{}) error {
+	localClient := e.Client
+	localClient.Endpoint = fmt.Sprintf(e.Client.Endpoint, e.ID) + path
+	return localClient.FetchJSON(ctx, v)
+}
+
+// ToCsv of fetched picks and gameweeks
+func (e *Entry) ToCsv() error {
+	prefix := fmt.Sprintf("fpl-entries/%d", e.ID)
+	if err := csv.StructSlice(e.PickRows(), prefix+"-picks"); err != nil {
+		return err
+	}
+	return csv.StructSlice(e.GameweekRows(), prefix+"-gameweeks")
+}
+
+// Gameweeks with fetched picks, sorted
+func (e *Entry) Gameweeks() []int {
+	gameweeks := make([]int, 0, len(e.Gw2Picks))
+	for gw := range e.Gw2Picks {
+		gameweeks = append(gameweeks, gw)
+	}
+	sort.Ints(gameweeks)
+	return gameweeks
+}
+
+// PickRows of every fetched gameweek joined to Players and ID2History
+func (e *Entry) PickRows() []PickRow {
+	id2Player := make(map[int]team.Player, len(e.Players))
+	for _, p := range e.Players {
+		id2Player[p.ID] = p
+	}
+
+	rows := []PickRow{}
+	for _, gw := range e.Gameweeks() {
+		picks := e.Gw2Picks[gw]
+		for _, pick := range picks.Picks {
+			p := id2Player[pick.Element]
+			gwPoints := GameweekPoints(e.ID2History[pick.Element], gw)
+			rows = append(rows, PickRow{
+				Entry:         e.ID,
+				Gameweek:      gw,
+				Position:      pick.Position,
+				PlayerID:      pick.Element,
+				WebName:       p.WebName,
+				TeamName:      p.TeamName,
+				RoleName:      p.RoleName,
+				Multiplier:    pick.Multiplier,
+				IsCaptain:     pick.IsCaptain,
+				IsViceCaptain: pick.IsViceCaptain,
+				NowCost:       p.NowCost,
+				GwPoints:      gwPoints,
+				PickPoints:    gwPoints * pick.Multiplier,
+				TotalPoints:   p.TotalPoints,
+				ActiveChip:    picks.ActiveChip,
+			})
+		}
+	}
+	return rows
+}
+
+// GameweekRows of every fetched gameweek with captaincy and chip
+func (e *Entry) GameweekRows() []GameweekRow {
+	id2Name := make(map[int]string, len(e.Players))
+	for _, p := range e.Players {
+		id2Name[p.ID] = p.WebName
+	}
+
+	rows := []GameweekRow{}
+	for _, gw := range e.Gameweeks() {
+		picks := e.Gw2Picks[gw]
+		h := picks.EntryHistory
+		row := GameweekRow{
+			Entry:              e.ID,
+			Gameweek:           gw,
+			Points:             h.Points,
+			TotalPoints:        h.TotalPoints,
+			Rank:               h.Rank,
+			OverallRank:        h.OverallRank,
+			Bank:               h.Bank,
+			Value:              h.Value,
+			EventTransfers:     h.EventTransfers,
+			EventTransfersCost: h.EventTransfersCost,
+			PointsOnBench:      h.PointsOnBench,
+			ActiveChip:         picks.ActiveChip,
+		}
+		for _, pick := range picks.Picks {
+			if pick.IsCaptain {
+				row.Captain = id2Name[pick.Element]
+			}
+			if pick.IsViceCaptain {
+				row.ViceCaptain = id2Name[pick.Element]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// GameweekPoints of a player in gw from element-summary history, doubles summed
+func GameweekPoints(historyList []element.History, gw int) (points int) {
+	for _, h := range historyList {
+		if h.Round == gw {
+			points += h.TotalPoints
+		}
+	}
+	return points
+}
+
+// StartingPositions of the picks, the rest is bench
+const StartingPositions = 11
+
+// Multiplier of pick before automatic substitutions, with captaincy, triple captain and bench boost
+func Multiplier(pick Pick, activeChip string) int {
+	if pick.Position > StartingPositions && activeChip != BenchBoost {
+		return 0
+	}
+	if pick.IsCaptain {
+		if activeChip == TripleCaptain {
+			return 3
+		}
+		return 2
+	}
+	return 1
+}
+
+// FreeTransfers available for the gameweek after the last one in History, up to maxFree.
+// Every gameweek after the entry's first adds one, a wildcard or free hit keeps them.
+func (e *Entry) FreeTransfers(maxFree int) int {
+	chipEvents := make(map[int]bool)
+	for _, chip := range e.History.Chips {
+		if chip.Name == Wildcard || chip.Name == FreeHit {
+			chipEvents[chip.Event] = true
+		}
+	}
+	free := 0
+	for i, gw := range e.History.Current {
+		switch {
+		case i == 0:
+			free = 1
+			continue
+		case !chipEvents[gw.Event]:
+			free -= gw.EventTransfers
+			if free < 0 {
+				free = 0
+			}
+		}
+		free++
+		if free > maxFree {
+			free = maxFree
+		}
+	}
+	return free
+}
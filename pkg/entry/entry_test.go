@@ -0,0 +1,101 @@
+package entry_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpltest"
+)
+
+func TestGetEntryToCsv(t *testing.T) {
+	season := fpltest.NewSeason(1, 6, 5, 3)
+	if err := season.AddEntry(1, "me", 7); err != nil {
+		t.Fatal(err)
+	}
+	srv := fpltest.NewServer(season)
+	defer srv.Close()
+
+	tests := []struct {
+		name          string
+		id            int
+		gameweeks     []int
+		wantGameweeks []int
+		wantStatus    int
+	}{
+		{name: "every played gameweek", id: 1, wantGameweeks: []int{1, 2, 3}},
+		{name: "given gameweeks", id: 1, gameweeks: []int{2}, wantGameweeks: []int{2}},
+		{name: "unplayed gameweek", id: 1, gameweeks: []int{2, 4}, wantStatus: http.StatusNotFound},
+		{name: "unknown entry", id: 2, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csv.Dir = t.TempDir()
+			defer func() { csv.Dir = "csv_out" }()
+
+			e := entry.Entry{
+				Client: client.GenericClient{
+					Endpoint: srv.EntryEndpoint(),
+					Retry:    client.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond},
+				},
+				ID:      tt.id,
+				Players: season.Bootstrap.Players,
+			}
+			err := e.GetEntryToCsv(context.Background(), tt.gameweeks)
+			if tt.wantStatus != 0 {
+				var statusErr *client.StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus {
+					t.Fatalf("GetEntryToCsv() error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetEntryToCsv() error = %v", err)
+			}
+			if got := e.Gameweeks(); !reflect.DeepEqual(got, tt.wantGameweeks) {
+				t.Errorf("Gameweeks() = %v, want %v", got, tt.wantGameweeks)
+			}
+			if got, want := len(e.PickRows()), 15*len(tt.wantGameweeks); got != want {
+				t.Errorf("PickRows() of %d picks, want %d", got, want)
+			}
+			for _, row := range e.GameweekRows() {
+				if row.Captain == "" || row.ViceCaptain == "" || row.Captain == row.ViceCaptain {
+					t.Errorf("GW%d captain %q vice %q", row.Gameweek, row.Captain, row.ViceCaptain)
+				}
+			}
+			for _, suffix := range []string{"picks", "gameweeks"} {
+				files, _ := filepath.Glob(filepath.Join(csv.Dir, "fpl-entries", "1-"+suffix+"-*.csv"))
+				if len(files) != 1 {
+					t.Errorf("%d %v files, want 1", len(files), suffix)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiplier(t *testing.T) {
+	tests := []struct {
+		name       string
+		pick       entry.Pick
+		activeChip string
+		want       int
+	}{
+		{name: "starter", pick: entry.Pick{Position: 3, Multiplier: 1}, want: 1},
+		{name: "captain", pick: entry.Pick{Position: 1, Multiplier: 2, IsCaptain: true}, want: 2},
+		{name: "triple captain", pick: entry.Pick{Position: 1, Multiplier: 3, IsCaptain: true}, activeChip: entry.TripleCaptain, want: 3},
+		{name: "bench", pick: entry.Pick{Position: 13, Multiplier: 0}, want: 0},
+		{name: "bench boost", pick: entry.Pick{Position: 13, Multiplier: 1}, activeChip: entry.BenchBoost, want: 1},
+	}
+	for _, tt := range tests {
+		if got := entry.Multiplier(tt.pick, tt.activeChip); got != tt.want {
+			t.Errorf("%v: Multiplier() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package fixtures provides structures and methods to manage api/fixtures and the difficulty ticker
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+)
+
+// Endpoint to get every fixture of the season
+var (
+	Endpoint = "https://fantasy.premierleague.com/api/fixtures/"
+)
+
+// Fixtures for season fixtures information
+type Fixtures struct {
+	Client          client.GenericClient
+	Registry        *registry.Registry
+	Res             []Fixture
+	Team2Gw2Fixture map[int]map[int][]Fixture
+}
+
+// Fixture ... to skip go-lint
+type Fixture struct {
+	ID                   int       `json:"id"`
+	Code                 int       `json:"code"`
+	Event                int       `json:"event"`
+	KickoffTime          time.Time `json:"kickoff_time"`
+	ProvisionalStartTime bool      `json:"provisional_start_time"`
+	Started              bool      `json:"started"`
+	Finished             bool      `json:"finished"`
+	FinishedProvisional  bool      `json:"finished_provisional"`
+	Minutes              int       `json:"minutes"`
+	TeamH                int       `json:"team_h"`
+	TeamA                int       `json:"team_a"`
+	TeamHScore           int       `json:"team_h_score"`
+	TeamAScore           int       `json:"team_a_score"`
+	TeamHDifficulty      int       `json:"team_h_difficulty"`
+	TeamADifficulty      int       `json:"team_a_difficulty"`
+	Stats                []Stat    `json:"stats"`
+}
+
+// Stat ... to skip go-lint
+type Stat struct {
+	Identifier string      `json:"identifier"`
+	A          []StatValue `json:"a"`
+	H          []StatValue `json:"h"`
+}
+
+// StatValue ... to skip go-lint
+type StatValue struct {
+	Value   int `json:"value"`
+	Element int `json:"element"`
+}
+
+// Row of a Fixture for csv.StructSlice
+type Row struct {
+	ID              int
+	Event           int
+	KickoffTime     time.Time
+	TeamH           string
+	TeamA           string
+	TeamHScore      int
+	TeamAScore      int
+	TeamHDifficulty int
+	TeamADifficulty int
+	Started         bool
+	Finished        bool
+}
+
+// GetFixturesToCsv from api/fixtures/
+func (x *Fixtures) GetFixturesToCsv(ctx context.Context) error {
+	start := time.Now()
+	defer func() {
+		log.Printf("Took %v to GetResponse from %v\n", time.Since(start), x.Client.Endpoint)
+	}()
+
+	if err := x.Fetch(ctx); err != nil {
+		return err
+	}
+
+	rows := make([]Row, 0, len(x.Res))
+	for _, f := range x.Res {
+		rows = append(rows, Row{
+			ID:              f.ID,
+			Event:           f.Event,
+			KickoffTime:     f.KickoffTime,
+			TeamH:           x.Registry.TeamName(f.TeamH),
+			TeamA:           x.Registry.TeamName(f.TeamA),
+			TeamHScore:      f.TeamHScore,
+			TeamAScore:      f.TeamAScore,
+			TeamHDifficulty: f.TeamHDifficulty,
+			TeamADifficulty: f.TeamADifficulty,
+			Started:         f.Started,
+			Finished:        f.Finished,
+		})
+	}
+	return csv.StructSlice(rows, "fpl-fixtures")
+}
+
+// Fetch from api/fixtures/ into Res and Team2Gw2Fixture
+func (x *Fixtures) Fetch(ctx context.Context) error {
+	res := []Fixture{}
+	if err := x.Client.FetchJSON(ctx, &res); err != nil {
+		return err
+	}
+	x.Res = res
+	x.fillFixturesPerTeam()
+	return nil
+}
+
+// fillFixturesPerTeam indexes Res by team and gameweek, unscheduled fixtures (event 0) included
+func (x *Fixtures) fillFixturesPerTeam() {
+	x.Team2Gw2Fixture = make(map[int]map[int][]Fixture)
+	for _, f := range x.Res {
+		for _, teamID := range []int{f.TeamH, f.TeamA} {
+			if _, ok := x.Team2Gw2Fixture[teamID]; !ok {
+				x.Team2Gw2Fixture[teamID] = make(map[int][]Fixture)
+			}
+			x.Team2Gw2Fixture[teamID][f.Event] = append(x.Team2Gw2Fixture[teamID][f.Event], f)
+		}
+	}
+}
+
+// TeamIDs playing in any fixture, sorted
+func (x *Fixtures) TeamIDs() []int {
+	teamIDs := make([]int, 0, len(x.Team2Gw2Fixture))
+	for teamID := range x.Team2Gw2Fixture {
+		teamIDs = append(teamIDs, teamID)
+	}
+	sort.Ints(teamIDs)
+	return teamIDs
+}
+
+// LastGameweek of the season, the latest with a scheduled fixture
+func (x *Fixtures) LastGameweek() int {
+	last := 0
+	for _, f := range x.Res {
+		if f.Event > last {
+			last = f.Event
+		}
+	}
+	return last
+}
+
+// FixtureCount of teamID in gameweek gw, 0 is a blank and 2+ a double gameweek
+func (x *Fixtures) FixtureCount(teamID, gw int) int {
+	return len(x.Team2Gw2Fixture[teamID][gw])
+}
+
+// IsBlank when teamID has no fixture in gameweek gw
+func (x *Fixtures) IsBlank(teamID, gw int) bool {
+	return x.FixtureCount(teamID, gw) == 0
+}
+
+// IsDouble when teamID has more than one fixture in gameweek gw
+func (x *Fixtures) IsDouble(teamID, gw int) bool {
+	return x.FixtureCount(teamID, gw) > 1
+}
+
+// Difficulty for teamID and its opponent in fixture f
+func Difficulty(f Fixture, teamID int) (difficulty, opponent int, isHome bool) {
+	if f.TeamH == teamID {
+		return f.TeamHDifficulty, f.TeamA, true
+	}
+	return f.TeamADifficulty, f.TeamH, false
+}
+
+// String of f, e.g. "ARS v CHE GW3"
+func (x *Fixtures) String(f Fixture) string {
+	return fmt.Sprintf("%v v %v GW%d", x.Registry.TeamName(f.TeamH), x.Registry.TeamName(f.TeamA), f.Event)
+}
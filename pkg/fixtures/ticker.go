@@ -0,0 +1,120 @@
+package fixtures
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+)
+
+// blankDifficulty counts a blank gameweek as the hardest possible fixture
+const blankDifficulty = 5
+
+// TickerRow of one team in one gameweek
+type TickerRow struct {
+	TeamID       int
+	Team         string
+	Gameweek     int
+	Opponents    string
+	FixtureCount int
+	Difficulty   int
+	Blank        bool
+	Double       bool
+}
+
+// TickerSummary of one team over the ticker gameweeks, AvgDifficulty is per fixture
+// with every blank counted as one fixture of the hardest difficulty
+type TickerSummary struct {
+	TeamID          int
+	Team            string
+	FromGameweek    int
+	ToGameweek      int
+	FixtureCount    int
+	Blanks          int
+	Doubles         int
+	TotalDifficulty int
+	AvgDifficulty   float64
+}
+
+// Ticker of every team for gameweeks from fromGW to fromGW+n-1, none past LastGameweek.
+// Opponents read like "CHE(H) ars(A)", away fixtures in lower case.
+func (x *Fixtures) Ticker(fromGW, n int) []TickerRow {
+	toGW := fromGW + n - 1
+	if last := x.LastGameweek(); toGW > last {
+		toGW = last
+	}
+	rows := []TickerRow{}
+	for _, teamID := range x.TeamIDs() {
+		for gw := fromGW; gw <= toGW; gw++ {
+			row := TickerRow{
+				TeamID:       teamID,
+				Team:         x.Registry.TeamName(teamID),
+				Gameweek:     gw,
+				FixtureCount: x.FixtureCount(teamID, gw),
+				Blank:        x.IsBlank(teamID, gw),
+				Double:       x.IsDouble(teamID, gw),
+			}
+			opponents := []string{}
+			for _, f := range x.Team2Gw2Fixture[teamID][gw] {
+				difficulty, opponent, isHome := Difficulty(f, teamID)
+				row.Difficulty += difficulty
+				if isHome {
+					opponents = append(opponents, fmt.Sprintf("%v(H)", strings.ToUpper(x.Registry.TeamName(opponent))))
+				} else {
+					opponents = append(opponents, fmt.Sprintf("%v(A)", strings.ToLower(x.Registry.TeamName(opponent))))
+				}
+			}
+			if row.Blank {
+				row.Difficulty = blankDifficulty
+				opponents = append(opponents, "-")
+			}
+			row.Opponents = strings.Join(opponents, " ")
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// TickerSummaries of rows per team, easiest run first
+func TickerSummaries(rows []TickerRow) []TickerSummary {
+	team2Summary := make(map[int]*TickerSummary)
+	teamIDs := []int{}
+	for _, row := range rows {
+		summary, ok := team2Summary[row.TeamID]
+		if !ok {
+			summary = &TickerSummary{TeamID: row.TeamID, Team: row.Team, FromGameweek: row.Gameweek}
+			team2Summary[row.TeamID] = summary
+			teamIDs = append(teamIDs, row.TeamID)
+		}
+		summary.ToGameweek = row.Gameweek
+		summary.FixtureCount += row.FixtureCount
+		summary.TotalDifficulty += row.Difficulty
+		if row.Blank {
+			summary.Blanks++
+		}
+		if row.Double {
+			summary.Doubles++
+		}
+	}
+
+	summaries := make([]TickerSummary, 0, len(teamIDs))
+	for _, teamID := range teamIDs {
+		summary := team2Summary[teamID]
+		summary.AvgDifficulty = float64(summary.TotalDifficulty) / float64(summary.FixtureCount+summary.Blanks)
+		summaries = append(summaries, *summary)
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].AvgDifficulty < summaries[j].AvgDifficulty
+	})
+	return summaries
+}
+
+// TickerToCsv for gameweeks from fromGW to fromGW+n-1, see Ticker
+func (x *Fixtures) TickerToCsv(fromGW, n int) error {
+	rows := x.Ticker(fromGW, n)
+	if err := csv.StructSlice(rows, "fpl-ticker"); err != nil {
+		return err
+	}
+	return csv.StructSlice(TickerSummaries(rows), "fpl-ticker-summary")
+}
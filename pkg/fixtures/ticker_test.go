@@ -0,0 +1,59 @@
+package fixtures_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpltest"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+)
+
+func TestTicker(t *testing.T) {
+	season := fpltest.NewSeason(1, 6, 8, 2)
+	srv := fpltest.NewServer(season)
+	defer srv.Close()
+	res := season.Bootstrap
+	x := &fixtures.Fixtures{
+		Client:   client.GenericClient{Endpoint: srv.FixturesEndpoint()},
+		Registry: registry.New(res.Players, res.Teams, res.PlayerRoles),
+	}
+	if err := x.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		fromGW, n     int
+		wantGameweeks []int
+	}{
+		{name: "mid season", fromGW: 3, n: 3, wantGameweeks: []int{3, 4, 5}},
+		{name: "end of the season", fromGW: 7, n: 5, wantGameweeks: []int{7, 8}},
+		{name: "season over", fromGW: 9, n: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := x.Ticker(tt.fromGW, tt.n)
+			team2Gameweeks := make(map[int][]int)
+			for _, row := range rows {
+				team2Gameweeks[row.TeamID] = append(team2Gameweeks[row.TeamID], row.Gameweek)
+			}
+			if len(tt.wantGameweeks) == 0 && len(rows) > 0 {
+				t.Errorf("Ticker() of %d rows, want none", len(rows))
+			}
+			for teamID, gameweeks := range team2Gameweeks {
+				if !reflect.DeepEqual(gameweeks, tt.wantGameweeks) {
+					t.Errorf("team %d ticker of %v, want %v", teamID, gameweeks, tt.wantGameweeks)
+				}
+			}
+			for _, summary := range fixtures.TickerSummaries(rows) {
+				// one fixture a gameweek, no blank past the season
+				if summary.Blanks != 0 || summary.FixtureCount != len(tt.wantGameweeks) {
+					t.Errorf("team %d summary %+v, want %d fixtures and no blank", summary.TeamID, summary, len(tt.wantGameweeks))
+				}
+			}
+		})
+	}
+}
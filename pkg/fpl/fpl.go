@@ -2,17 +2,19 @@
 package fpl
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"math"
+	"sort"
 	"strconv"
 	"time"
 
+	"github.com/jadugnap/golang-fpl-101/pkg/aggregate"
 	"github.com/jadugnap/golang-fpl-101/pkg/client"
 	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
 	"github.com/jadugnap/golang-fpl-101/pkg/team"
-	"github.com/jadugnap/golang-fpl-101/proto/pb"
 )
 
 // Endpoint to get main fpl response
@@ -24,15 +26,32 @@ var (
 type FPL struct {
 	Client         client.GenericClient
 	Res            Response
+	Registry       *registry.Registry
 	Team2Player    map[string][]team.Player
-	Team           string
-	Players        []team.Player
 	Team2Gw2Points map[string]map[int]int
 }
 
+// TeamSummary with cumulative information per team, computed by calcTeamSummary
+type TeamSummary struct {
+	TeamID             int
+	TeamName           string
+	PlayerCount        int
+	RegularPlayerCount int
+	PointsPerGame      float64
+	OppPointsPerGame   float64
+	Form               float64
+	TotalPoints        int
+	ValueForm          float64
+	ValueSeason        float64
+	IctIndex           float64
+	NowCost            int
+	Minutes            int
+}
+
 // Response from api/bootstrap-static/
 type Response struct {
 	// not used
+	Chips        []Chip        `json:"chips"`
 	ElementStats []ElementStat `json:"element_stats"`
 	Events       []Event       `json:"events"`
 	GameSettings GameSetting   `json:"game_settings"`
@@ -44,6 +63,17 @@ type Response struct {
 	Teams       []team.Team        `json:"teams"`
 }
 
+// Chip of a set playable from StartEvent to StopEvent, Number 1 for the first
+// half of the season and 2 for the second
+type Chip struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Number     int    `json:"number"`
+	StartEvent int    `json:"start_event"`
+	StopEvent  int    `json:"stop_event"`
+	ChipType   string `json:"chip_type"`
+}
+
 // ElementStat ... to skip go-lint
 type ElementStat struct {
 	Label string `json:"label"`
@@ -127,26 +157,23 @@ type TopElementInfo struct {
 }
 
 // GetFplResponseToCsv from api/bootstrap-static/
-func (f *FPL) GetFplResponseToCsv() {
+func (f *FPL) GetFplResponseToCsv(ctx context.Context) {
 	start := time.Now()
 	defer func() {
 		log.Printf("Took %v to GetResponse from %v\n", time.Since(start), f.Client.Endpoint)
 	}()
 
-	bodyBytes := f.Client.GetResponse()
-	if err := json.Unmarshal(bodyBytes, &f.Res); err != nil {
-		log.Println("error json.Unmarshal():", err)
+	if err := f.Client.FetchJSON(ctx, &f.Res); err != nil {
+		log.Println("error Client.FetchJSON():", err)
 		return
 	}
 
+	f.Registry = registry.New(f.Res.Players, f.Res.Teams, f.Res.PlayerRoles)
 	f.fillPlayersPerTeam()
 
 	// for team, players := range f.Team2Player {
-	// 	f.Team = team
-	// 	f.Players = players
-	// 	f.addSummaryRow()
 	// 	teamPrefix := fmt.Sprintf("fpl-players/%+v", team)
-	// 	csv.StructSlice(f.Players, teamPrefix)
+	// 	csv.StructSlice(players, teamPrefix)
 	// }
 	// csv.StructSlice(f.Res.Players, "fpl-players/allteam")
 	// csv.StructSlice(f.Res.PlayerRoles, "fpl-roles")
@@ -154,20 +181,56 @@ func (f *FPL) GetFplResponseToCsv() {
 	return
 }
 
-// ToCsv from Fpl Response info
-func (f *FPL) ToCsv() {
-	// f.fillOpponentPoints()
+// ToCsv from Fpl Response info but Res.Players and Res.Teams themselves, left to
+// the caller's storage.Store
+func (f *FPL) ToCsv() error {
 	for team, players := range f.Team2Player {
-		f.Team = team
-		f.Players = players
-		f.addSummaryRow()
 		teamPrefix := fmt.Sprintf("fpl-players/%+v", team)
-		csv.StructSlice(f.Players, teamPrefix)
+		if err := csv.StructSlice(players, teamPrefix); err != nil {
+			return err
+		}
 	}
-	csv.StructSlice(f.Res.Players, "fpl-players/allteam")
-	csv.StructSlice(f.Res.PlayerRoles, "fpl-roles")
-	csv.StructSlice(f.Res.Teams, "fpl-teams")
-	return
+	if err := csv.StructSlice(f.TeamSummaries(), "fpl-teams-summary"); err != nil {
+		return err
+	}
+	metrics, err := aggregate.Aggregator{GroupBy: aggregate.ByTeamPosition, Metrics: Metrics}.Compute(f.Res.Players)
+	if err != nil {
+		return err
+	}
+	if err := csv.StructSlice(aggregate.Rows(metrics), "fpl-teams-metrics"); err != nil {
+		return err
+	}
+	if err := csv.StructSlice(f.Res.PlayerRoles, "fpl-roles"); err != nil {
+		return err
+	}
+	if err := csv.StructSlice(f.Res.Events, "fpl-events"); err != nil {
+		return err
+	}
+	return csv.StructSlice([]GameSetting{f.Res.GameSettings}, "fpl-game-settings")
+}
+
+// CurrentEvent ID, 0 before the season starts
+func (f *FPL) CurrentEvent() int {
+	for _, event := range f.Res.Events {
+		if event.IsCurrent {
+			return event.ID
+		}
+	}
+	return 0
+}
+
+// NextEvent ID, the gameweek after the current one when none is flagged next
+func (f *FPL) NextEvent() int {
+	current := 0
+	for _, event := range f.Res.Events {
+		if event.IsNext {
+			return event.ID
+		}
+		if event.IsCurrent {
+			current = event.ID
+		}
+	}
+	return current + 1
 }
 
 // fillPlayersPerTeam with positions and teams related info
@@ -175,9 +238,9 @@ func (f *FPL) ToCsv() {
 func (f *FPL) fillPlayersPerTeam() {
 	f.Team2Player = make(map[string][]team.Player)
 	for i, p := range f.Res.Players {
-		playerName := pb.Player_Webname_name[int32(p.ID)]
-		teamName := pb.Team_Shortname_name[int32(p.TeamID)]
-		posName := pb.Player_Position_name[int32(p.RoleID)]
+		playerName := f.Registry.PlayerName(p.ID)
+		teamName := f.Registry.TeamName(p.TeamID)
+		posName := f.Registry.PositionName(p.RoleID)
 		f.Res.Players[i].WebName = playerName
 		f.Res.Players[i].TeamName = teamName
 		f.Res.Players[i].RoleName = posName
@@ -192,49 +255,47 @@ func (f *FPL) fillPlayersPerTeam() {
 	}
 }
 
-// addSummaryRow with team cumulative information per team
-func (f *FPL) addSummaryRow() {
-	if len(f.Players) == 0 {
-		log.Println("unable to addSummaryRow() due to empty f.Players")
-		return
+// TeamSummaries of every team in Team2Player, ordered by team name
+func (f *FPL) TeamSummaries() []TeamSummary {
+	teamNames := make([]string, 0, len(f.Team2Player))
+	for teamName := range f.Team2Player {
+		teamNames = append(teamNames, teamName)
 	}
-	p0 := f.Players[0]
-	summary := team.Player{
-		ID:       p0.TeamID + 1000,
-		WebName:  fmt.Sprintf("AllPlayed_%s_Players", p0.TeamName),
-		TeamName: p0.TeamName,
-		RoleName: "all",
+	sort.Strings(teamNames)
+
+	summaries := make([]TeamSummary, 0, len(teamNames))
+	for _, teamName := range teamNames {
+		players := f.Team2Player[teamName]
+		if len(players) == 0 {
+			log.Printf("unable to calcTeamSummary() due to empty players of %v\n", teamName)
+			continue
+		}
+		summaries = append(summaries, calcTeamSummary(players, f.calcOpponentPoints(teamName)))
+	}
+	return summaries
+}
+
+// calcTeamSummary with team cumulative information, players are left untouched
+func calcTeamSummary(players []team.Player, oppTotalPoints int) TeamSummary {
+	p0 := players[0]
+	summary := TeamSummary{
 		TeamID:   p0.TeamID,
-		RoleID:   0,
-		// PlayerCount:        "-1",
-		// RegularPlayerCount: "-1",
-		// PointsPerGame:      "-1",
-		// OppPointsPerGame:   "-1",
-		// Form:               "-1",
-		TotalPoints: 0,
-		// ValueForm:          "-1",
-		// ValueSeason:        "-1",
-		// IctIndex:           "-1",
-		NowCost: 0,
-		Minutes: 0,
+		TeamName: p0.TeamName,
 	}
 
 	// for int, sum up all the values
-	for _, player := range f.Players {
+	for _, player := range players {
 		summary.TotalPoints += player.TotalPoints
 		summary.NowCost += player.NowCost
 		summary.Minutes += player.Minutes
 	}
-	oppTotalPoints := float64(f.calcOpponentPoints())
 	matchPlayed := math.Round(float64(summary.Minutes) / 990)
 	currentPrice := float64(summary.NowCost) / 10.0
 
-	// for float, convert strings => sum up all floats => string
+	// for float, convert strings => sum up all floats
 	tempIctIndex := 0.0
 	tempForm := 0.0
-	// pCount := 0.0
-	// rpCount := 0.0
-	for _, player := range f.Players {
+	for _, player := range players {
 		if player.Minutes <= 0 {
 			continue
 		} else if player.Minutes >= int(matchPlayed*90) {
@@ -250,27 +311,25 @@ func (f *FPL) addSummaryRow() {
 		tempForm += pForm
 	}
 
-	// convert floats => string
-	summary.IctIndex = fmt.Sprintf("%.2f", tempIctIndex/float64(summary.RegularPlayerCount))
-	summary.ValueForm = fmt.Sprintf("%.2f", tempForm/currentPrice)
-	summary.ValueSeason = fmt.Sprintf("%.2f", float64(summary.TotalPoints)/currentPrice)
-	summary.Form = fmt.Sprintf("%.2f", tempForm)
-	summary.OppPointsPerGame = fmt.Sprintf("%.2f", oppTotalPoints/matchPlayed)
-	summary.PointsPerGame = fmt.Sprintf("%.2f", float64(summary.TotalPoints)/matchPlayed)
-
-	f.Players = append([]team.Player{summary}, f.Players...)
-	f.Res.Players = append([]team.Player{summary}, f.Res.Players...)
-	return
+	summary.Form = tempForm
+	if summary.RegularPlayerCount > 0 {
+		summary.IctIndex = tempIctIndex / float64(summary.RegularPlayerCount)
+	}
+	if currentPrice > 0 {
+		summary.ValueForm = tempForm / currentPrice
+		summary.ValueSeason = float64(summary.TotalPoints) / currentPrice
+	}
+	if matchPlayed > 0 {
+		summary.OppPointsPerGame = float64(oppTotalPoints) / matchPlayed
+		summary.PointsPerGame = float64(summary.TotalPoints) / matchPlayed
+	}
+	return summary
 }
 
 // calcOpponentPoints from a map obtained from element-summary info
-func (f *FPL) calcOpponentPoints() (oppTotalPoints int) {
-	for team, innerMap := range f.Team2Gw2Points {
-		if team == f.Team {
-			for _, gameweekPoint := range innerMap {
-				oppTotalPoints += gameweekPoint
-			}
-		}
+func (f *FPL) calcOpponentPoints(teamName string) (oppTotalPoints int) {
+	for _, gameweekPoint := range f.Team2Gw2Points[teamName] {
+		oppTotalPoints += gameweekPoint
 	}
 	return oppTotalPoints
 }
@@ -0,0 +1,99 @@
+package fpl_test
+
+import (
+	"context"
+	stdcsv "encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpltest"
+)
+
+func TestToCsv(t *testing.T) {
+	srv := fpltest.NewServer(fpltest.NewSeason(1, 6, 4, 2))
+	defer srv.Close()
+	csv.Dir = t.TempDir()
+	defer func() { csv.Dir = "csv_out" }()
+
+	f := fpl.FPL{Client: client.GenericClient{Endpoint: srv.BootstrapEndpoint()}}
+	f.GetFplResponseToCsv(context.Background())
+	if err := f.ToCsv(); err != nil {
+		t.Fatalf("ToCsv() error = %v", err)
+	}
+
+	tests := []struct {
+		prefix string
+		// wantRows without the header, -1 for any but none
+		wantRows   int
+		wantColumn string
+	}{
+		{prefix: "fpl-players/T01", wantRows: 15, wantColumn: "NowCost"},
+		{prefix: "fpl-teams-summary", wantRows: 6, wantColumn: "TeamName"},
+		{prefix: "fpl-teams-metrics", wantRows: -1},
+		{prefix: "fpl-roles", wantRows: 4},
+		{prefix: "fpl-events", wantRows: 4, wantColumn: "DeadlineTime"},
+		{prefix: "fpl-game-settings", wantRows: 1, wantColumn: "SquadTotalSpend"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			records := readCsv(t, tt.prefix)
+			rows := len(records) - 1
+			if (tt.wantRows < 0 && rows <= 0) || (tt.wantRows >= 0 && rows != tt.wantRows) {
+				t.Errorf("%v has %d rows, want %d", tt.prefix, rows, tt.wantRows)
+			}
+			if tt.wantColumn == "" {
+				return
+			}
+			for _, name := range records[0] {
+				if name == tt.wantColumn {
+					return
+				}
+			}
+			t.Errorf("%v header %v has no %v", tt.prefix, records[0], tt.wantColumn)
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	tests := []struct {
+		played      int
+		wantCurrent int
+		wantNext    int
+	}{
+		{played: 0, wantCurrent: 0, wantNext: 1},
+		{played: 2, wantCurrent: 2, wantNext: 3},
+		{played: 4, wantCurrent: 4, wantNext: 5},
+	}
+	for _, tt := range tests {
+		f := fpl.FPL{Res: fpltest.NewSeason(1, 6, 4, tt.played).Bootstrap}
+		if got := f.CurrentEvent(); got != tt.wantCurrent {
+			t.Errorf("played %d: CurrentEvent() = %d, want %d", tt.played, got, tt.wantCurrent)
+		}
+		if got := f.NextEvent(); got != tt.wantNext {
+			t.Errorf("played %d: NextEvent() = %d, want %d", tt.played, got, tt.wantNext)
+		}
+	}
+}
+
+// readCsv records of the only file written with prefix into csv.Dir
+func readCsv(t *testing.T, prefix string) [][]string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(csv.Dir, prefix+"-[0-9]*.csv"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("files of %v = %v, %v, want exactly one", prefix, matches, err)
+	}
+	file, err := os.Open(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := stdcsv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("%v: %v", matches[0], err)
+	}
+	return records
+}
@@ -0,0 +1,12 @@
+package fpl
+
+import "github.com/jadugnap/golang-fpl-101/pkg/aggregate"
+
+// Metrics of team.Player rows, the aggregate.Common ones and team.Player only ones.
+// Register more on it to have them in fpl-teams-metrics.
+var Metrics = aggregate.MustNewRegistry(append(aggregate.Common(),
+	aggregate.Metric{Name: "now_cost", Field: "NowCost", Reducer: aggregate.Sum},
+	aggregate.Metric{Name: "form", Field: "Form", Reducer: aggregate.Sum},
+	aggregate.Metric{Name: "form_per_million", Field: "Form", Reducer: aggregate.PerMillion},
+	aggregate.Metric{Name: "ict_index", Field: "IctIndex", Reducer: aggregate.Mean, Filter: aggregate.Played},
+)...)
@@ -0,0 +1,269 @@
+package fpltest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/league"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// cheapestCost kept aside for every squad slot still to fill
+const cheapestCost = 40
+
+// squadAttempts of randomSquad before giving up on a budget or team limit too tight
+const squadAttempts = 100
+
+// AddEntry with a random valid squad kept for every finished gameweek,
+// captain and vice captain on its two priciest starters, points from Live.
+// An error when no full squad fits the game settings.
+func (season *Season) AddEntry(id int, name string, seed int64) error {
+	r := rand.New(rand.NewSource(seed))
+	settings := season.Bootstrap.GameSettings
+	squad, err := randomSquad(r, season.Bootstrap.Players, settings.SquadTotalSpend, settings.SquadTeamLimit)
+	if err != nil {
+		return fmt.Errorf("entry %d: %w", id, err)
+	}
+	spent := 0
+	for _, p := range squad {
+		spent += p.NowCost
+	}
+
+	picks := make([]entry.Pick, len(squad))
+	for i, p := range squad {
+		picks[i] = entry.Pick{Element: p.ID, Position: i + 1, Multiplier: 1}
+		if i >= settings.SquadSquadplay {
+			picks[i].Multiplier = 0
+		}
+	}
+	starters := append([]entry.Pick{}, picks[:settings.SquadSquadplay]...)
+	sort.SliceStable(starters, func(i, j int) bool {
+		return squad[starters[i].Position-1].NowCost > squad[starters[j].Position-1].NowCost
+	})
+	picks[starters[0].Position-1].IsCaptain = true
+	picks[starters[0].Position-1].Multiplier = 2
+	picks[starters[1].Position-1].IsViceCaptain = true
+
+	history := entry.HistoryResponse{}
+	season.Picks[id] = make(map[int]entry.PicksResponse)
+	total := 0
+	for _, event := range season.Bootstrap.Events {
+		if !event.Finished {
+			continue
+		}
+		id2Points := make(map[int]int)
+		for _, e := range season.Live[event.ID].Elements {
+			id2Points[e.ID] = e.Stats.TotalPoints
+		}
+		gw := entry.GameweekHistory{
+			Event: event.ID,
+			Bank:  settings.SquadTotalSpend - spent,
+			Value: spent,
+		}
+		for _, pick := range picks {
+			gw.Points += pick.Multiplier * id2Points[pick.Element]
+			if pick.Multiplier == 0 {
+				gw.PointsOnBench += id2Points[pick.Element]
+			}
+		}
+		total += gw.Points
+		gw.TotalPoints = total
+		history.Current = append(history.Current, gw)
+		season.Picks[id][event.ID] = entry.PicksResponse{
+			EntryHistory: gw,
+			Picks:        append([]entry.Pick{}, picks...),
+		}
+	}
+
+	season.EntryHistories[id] = history
+	season.Transfers[id] = []entry.Transfer{}
+	season.Entries[id] = entry.Response{
+		ID:                   id,
+		Name:                 name,
+		StartedEvent:         1,
+		CurrentEvent:         len(history.Current),
+		SummaryOverallPoints: total,
+		LastDeadlineBank:     settings.SquadTotalSpend - spent,
+		LastDeadlineValue:    spent,
+	}
+	return nil
+}
+
+// randomSquad of SquadShape within budget and teamLimit, ordered as picks:
+// StarterShape first by role, then the bench goalkeeper and outfielders.
+// Greedy picks are retried squadAttempts times before an error.
+func randomSquad(r *rand.Rand, players []team.Player, budget, teamLimit int) ([]team.Player, error) {
+	slots := 0
+	for _, n := range SquadShape {
+		slots += n
+	}
+	for attempt := 0; attempt < squadAttempts; attempt++ {
+		if chosen := greedySquad(r, players, budget, teamLimit); len(chosen) == slots {
+			return order(chosen), nil
+		}
+	}
+	return nil, fmt.Errorf("no squad of %d players within budget %d and %d per club", slots, budget, teamLimit)
+}
+
+// greedySquad of players in random order while they fit, possibly short of SquadShape
+func greedySquad(r *rand.Rand, players []team.Player, budget, teamLimit int) []team.Player {
+	need := make(map[int]int)
+	slots := 0
+	for roleID, n := range SquadShape {
+		need[roleID] = n
+		slots += n
+	}
+	clubCount := make(map[int]int)
+	spent := 0
+	chosen := []team.Player{}
+	for _, i := range r.Perm(len(players)) {
+		p := players[i]
+		if need[p.RoleID] == 0 || clubCount[p.TeamID] >= teamLimit {
+			continue
+		}
+		if spent+p.NowCost+cheapestCost*(slots-len(chosen)-1) > budget {
+			continue
+		}
+		need[p.RoleID]--
+		clubCount[p.TeamID]++
+		spent += p.NowCost
+		chosen = append(chosen, p)
+		if len(chosen) == slots {
+			break
+		}
+	}
+	return chosen
+}
+
+// order chosen as picks, StarterShape first by role then the bench
+func order(chosen []team.Player) []team.Player {
+	starters, bench := []team.Player{}, []team.Player{}
+	for roleID := 1; roleID <= 4; roleID++ {
+		n := 0
+		for _, p := range chosen {
+			if p.RoleID != roleID {
+				continue
+			}
+			if n < StarterShape[roleID] {
+				starters = append(starters, p)
+			} else {
+				bench = append(bench, p)
+			}
+			n++
+		}
+	}
+	return append(starters, bench...)
+}
+
+// AddClassicLeague of entries already added with AddEntry
+func (season *Season) AddClassicLeague(id int, name string, entryIDs []int) {
+	season.ClassicLeagues[id] = season.newLeague(id, name, "c", entryIDs)
+}
+
+// AddH2HLeague of entries already added with AddEntry, paired round-robin every
+// gameweek and ranked by match points then points for
+func (season *Season) AddH2HLeague(id int, name string, entryIDs []int) {
+	season.H2HLeagues[id] = season.newLeague(id, name, "h", entryIDs)
+}
+
+// newLeague standings from the entries' latest gameweek
+func (season *Season) newLeague(id int, name, scoring string, entryIDs []int) league.Response {
+	res := league.Response{
+		League: league.Info{ID: id, Name: name, Scoring: scoring, StartEvent: 1},
+	}
+	for _, entryID := range entryIDs {
+		standing := league.Standing{
+			ID:        entryID,
+			Entry:     entryID,
+			EntryName: season.Entries[entryID].Name,
+		}
+		if current := season.EntryHistories[entryID].Current; len(current) > 0 {
+			standing.EventTotal = current[len(current)-1].Points
+			standing.Total = current[len(current)-1].TotalPoints
+		}
+		res.Standings.Results = append(res.Standings.Results, standing)
+	}
+	results := res.Standings.Results
+	if scoring == league.H2HScoring {
+		season.H2HMatches[id] = playH2H(season, results)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Total != results[j].Total {
+			return results[i].Total > results[j].Total
+		}
+		return results[i].PointsFor > results[j].PointsFor
+	})
+	for i := range results {
+		results[i].Rank = i + 1
+		results[i].RankSort = i + 1
+	}
+	return res
+}
+
+// playH2H matches of every gameweek so far between standings, rotated round-robin with
+// the odd one out sitting the gameweek out, Total becoming match points (3 a win, 1 a draw)
+func playH2H(season *Season, standings []league.Standing) []league.Match {
+	gw2Points := make(map[int]map[int]int, len(standings))
+	gameweeks := 0
+	for _, s := range standings {
+		gw2Points[s.Entry] = make(map[int]int)
+		for _, h := range season.EntryHistories[s.Entry].Current {
+			gw2Points[s.Entry][h.Event] = h.Points
+			if h.Event > gameweeks {
+				gameweeks = h.Event
+			}
+		}
+	}
+	order := make([]int, len(standings))
+	for i := range order {
+		order[i] = i
+	}
+	for i := range standings {
+		standings[i].Total = 0
+	}
+	matches := []league.Match{}
+	for gw := 1; gw <= gameweeks; gw++ {
+		for k := 0; k+1 < len(order); k += 2 {
+			a, b := &standings[order[k]], &standings[order[k+1]]
+			pa, pb := gw2Points[a.Entry][gw], gw2Points[b.Entry][gw]
+			m := league.Match{
+				ID:           len(matches) + 1,
+				Event:        gw,
+				Entry1Entry:  a.Entry,
+				Entry1Points: pa,
+				Entry2Entry:  b.Entry,
+				Entry2Points: pb,
+			}
+			a.MatchesPlayed++
+			b.MatchesPlayed++
+			a.PointsFor += pa
+			b.PointsFor += pb
+			switch {
+			case pa > pb:
+				a.MatchesWon++
+				b.MatchesLost++
+				a.Total += 3
+				m.Entry1Win, m.Entry2Loss = 1, 1
+			case pa < pb:
+				b.MatchesWon++
+				a.MatchesLost++
+				b.Total += 3
+				m.Entry2Win, m.Entry1Loss = 1, 1
+			default:
+				a.MatchesDrawn++
+				b.MatchesDrawn++
+				a.Total++
+				b.Total++
+				m.Entry1Draw, m.Entry2Draw = 1, 1
+			}
+			matches = append(matches, m)
+		}
+		if len(order) > 2 {
+			// keep the first in place and rotate the rest
+			order = append([]int{order[0], order[len(order)-1]}, order[1:len(order)-1]...)
+		}
+	}
+	return matches
+}
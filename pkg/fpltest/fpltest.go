@@ -0,0 +1,305 @@
+// Package fpltest provides an in-process fake fpl api server
+package fpltest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+	"github.com/jadugnap/golang-fpl-101/pkg/league"
+	"github.com/jadugnap/golang-fpl-101/pkg/live"
+)
+
+// LeaguePageSize of league standings, as the api
+const LeaguePageSize = 50
+
+// Season served by Server, any endpoint without data responds 404
+type Season struct {
+	Bootstrap      fpl.Response
+	Summaries      map[int]element.SummaryResponse
+	Fixtures       []fixtures.Fixture
+	Live           map[int]live.Response
+	Entries        map[int]entry.Response
+	EntryHistories map[int]entry.HistoryResponse
+	Transfers      map[int][]entry.Transfer
+	Picks          map[int]map[int]entry.PicksResponse
+	ClassicLeagues map[int]league.Response
+	H2HLeagues     map[int]league.Response
+	H2HMatches     map[int][]league.Match
+}
+
+// Faults injected into matching responses
+type Faults struct {
+	// Paths restricts faults to request paths containing any of them, empty for all
+	Paths   []string
+	Latency time.Duration
+	// FailFirst requests per path respond FailStatus, then FailRate of the rest
+	FailFirst  int
+	FailRate   float64
+	FailStatus int
+	RetryAfter string
+	// Truncate cuts every json body in half
+	Truncate bool
+}
+
+// Server is a fake fantasy.premierleague.com serving Season, every body with an ETag
+// answered by 304 Not Modified on If-None-Match
+type Server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	season Season
+	faults Faults
+	hits   map[string]int
+	rand   *rand.Rand
+}
+
+// NewServer started with season, Close it when done
+func NewServer(season Season) *Server {
+	s := &Server{
+		season: season,
+		hits:   make(map[string]int),
+		rand:   rand.New(rand.NewSource(1)),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// UpdateSeason in place, e.g. to move to the next gameweek mid-test
+func (s *Server) UpdateSeason(update func(*Season)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(&s.season)
+}
+
+// SetFaults for subsequent requests, hit counters restart
+func (s *Server) SetFaults(faults Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = faults
+	s.hits = make(map[string]int)
+}
+
+// Hits on request paths containing path
+func (s *Server) Hits(path string) (total int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p, n := range s.hits {
+		if strings.Contains(p, path) {
+			total += n
+		}
+	}
+	return total
+}
+
+// BootstrapEndpoint to use as fpl.Endpoint
+func (s *Server) BootstrapEndpoint() string {
+	return s.URL + "/api/bootstrap-static/"
+}
+
+// ElementSummaryEndpoint to use as element.RawEndpoint
+func (s *Server) ElementSummaryEndpoint() string {
+	return s.URL + "/api/element-summary/%d/"
+}
+
+// FixturesEndpoint of api/fixtures/
+func (s *Server) FixturesEndpoint() string {
+	return s.URL + "/api/fixtures/"
+}
+
+// LiveEndpoint of api/event/{gw}/live/
+func (s *Server) LiveEndpoint() string {
+	return s.URL + "/api/event/%d/live/"
+}
+
+// EntryEndpoint of api/entry/{id}/
+func (s *Server) EntryEndpoint() string {
+	return s.URL + "/api/entry/%d/"
+}
+
+// ClassicLeagueEndpoint to use as league.RawClassicEndpoint
+func (s *Server) ClassicLeagueEndpoint() string {
+	return s.URL + "/api/leagues-classic/%d/standings/?page_standings=%d"
+}
+
+// H2HLeagueEndpoint to use as league.RawH2HEndpoint
+func (s *Server) H2HLeagueEndpoint() string {
+	return s.URL + "/api/leagues-h2h/%d/standings/?page_standings=%d"
+}
+
+// H2HMatchesEndpoint to use as league.RawH2HMatchesEndpoint
+func (s *Server) H2HMatchesEndpoint() string {
+	return s.URL + "/api/leagues-h2h-matches/league/%d/?page=%d"
+}
+
+// serveHTTP routes api paths onto Season
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.hits[r.URL.Path]++
+	hit := s.hits[r.URL.Path]
+	faults := s.faults
+	fail := faults.matches(r.URL.Path) &&
+		(hit <= faults.FailFirst || (faults.FailRate > 0 && s.rand.Float64() < faults.FailRate))
+	data, found := s.route(strings.Split(strings.Trim(r.URL.Path, "/"), "/"), r.URL.Query())
+	s.mu.Unlock()
+
+	if faults.matches(r.URL.Path) && faults.Latency > 0 {
+		select {
+		case <-time.After(faults.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if fail {
+		status := faults.FailStatus
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		if faults.RetryAfter != "" {
+			w.Header().Set("Retry-After", faults.RetryAfter)
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	bodyBytes, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// revalidation against the full body, a truncated one still carries its ETag
+	sum := sha1.Sum(bodyBytes)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if faults.matches(r.URL.Path) && faults.Truncate {
+		bodyBytes = bodyBytes[:len(bodyBytes)/2]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bodyBytes)
+}
+
+// route path segments, e.g. [api element-summary 1], onto Season data
+func (s *Server) route(segments []string, query url.Values) (interface{}, bool) {
+	if len(segments) < 2 || segments[0] != "api" {
+		return nil, false
+	}
+	ids := []int{}
+	for _, segment := range segments[2:] {
+		if id, err := strconv.Atoi(segment); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	route := fmt.Sprintf("%v/%d", segments[1], len(ids))
+	if last := segments[len(segments)-1]; len(segments) > 3 && len(ids) > 0 && last != strconv.Itoa(ids[len(ids)-1]) {
+		route += "/" + last
+	}
+
+	var data interface{}
+	found := false
+	switch route {
+	case "bootstrap-static/0":
+		data, found = s.season.Bootstrap, true
+	case "element-summary/1":
+		data, found = s.season.Summaries[ids[0]]
+	case "fixtures/0":
+		data, found = s.season.Fixtures, s.season.Fixtures != nil
+	case "event/1/live":
+		data, found = s.season.Live[ids[0]]
+	case "entry/1":
+		data, found = s.season.Entries[ids[0]]
+	case "entry/1/history":
+		data, found = s.season.EntryHistories[ids[0]]
+	case "entry/1/transfers":
+		data, found = s.season.Transfers[ids[0]]
+	case "entry/2/picks":
+		data, found = s.season.Picks[ids[0]][ids[1]]
+	case "leagues-classic/1/standings":
+		var res league.Response
+		if res, found = s.season.ClassicLeagues[ids[0]]; found {
+			data = paginate(res, query.Get("page_standings"))
+		}
+	case "leagues-h2h/1/standings":
+		var res league.Response
+		if res, found = s.season.H2HLeagues[ids[0]]; found {
+			data = paginate(res, query.Get("page_standings"))
+		}
+	case "leagues-h2h-matches/1":
+		var matches []league.Match
+		if matches, found = s.season.H2HMatches[ids[0]]; found {
+			data = paginateMatches(matches, query.Get("page"))
+		}
+	}
+	return data, found
+}
+
+// paginate league standings by LeaguePageSize, page defaults to 1
+func paginate(res league.Response, page string) league.Response {
+	n, from, to := pageBounds(page, len(res.Standings.Results))
+	res.Standings = league.Standings{
+		HasNext: to < len(res.Standings.Results),
+		Page:    n,
+		Results: res.Standings.Results[from:to],
+	}
+	return res
+}
+
+// paginateMatches of an h2h league by LeaguePageSize, page defaults to 1
+func paginateMatches(matches []league.Match, page string) league.MatchesResponse {
+	n, from, to := pageBounds(page, len(matches))
+	return league.MatchesResponse{
+		HasNext: to < len(matches),
+		Page:    n,
+		Results: matches[from:to],
+	}
+}
+
+// pageBounds of page n among total results, from inclusive and to exclusive
+func pageBounds(page string, total int) (n, from, to int) {
+	n, err := strconv.Atoi(page)
+	if err != nil || n < 1 {
+		n = 1
+	}
+	from = (n - 1) * LeaguePageSize
+	if from > total {
+		from = total
+	}
+	to = from + LeaguePageSize
+	if to > total {
+		to = total
+	}
+	return n, from, to
+}
+
+// matches when faults apply to path
+func (f Faults) matches(path string) bool {
+	if len(f.Paths) == 0 {
+		return true
+	}
+	for _, p := range f.Paths {
+		if strings.Contains(path, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,508 @@
+package fpltest
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/bonus"
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+	"github.com/jadugnap/golang-fpl-101/pkg/league"
+	"github.com/jadugnap/golang-fpl-101/pkg/live"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// SquadShape per team generated by NewSeason and StarterShape of its regulars, indexed by element_type
+var (
+	SquadShape   = map[int]int{1: 2, 2: 5, 3: 5, 4: 3}
+	StarterShape = map[int]int{1: 1, 2: 4, 3: 4, 4: 2}
+	roleNames    = map[int]string{1: "GKP", 2: "DEF", 3: "MID", 4: "FWD"}
+	rolePlay     = map[int][2]int{1: {1, 1}, 2: {3, 5}, 3: {2, 5}, 4: {1, 3}}
+)
+
+// SeasonStart kickoff of the first generated gameweek
+var SeasonStart = time.Date(2020, time.September, 12, 14, 0, 0, 0, time.UTC)
+
+// match generated by NewSeason
+type match struct {
+	id                   int
+	event                int
+	home, away           int
+	homeScore, awayScore int
+	homeDiff, awayDiff   int
+	kickoff              time.Time
+	finished             bool
+	stats                []fixtures.Stat
+}
+
+// NewSeason with numTeams (even) round-robin over gameweeks, of which played are finished.
+// The same seed always generates the same season.
+func NewSeason(seed int64, numTeams, gameweeks, played int) Season {
+	r := rand.New(rand.NewSource(seed))
+	season := Season{
+		Summaries:      make(map[int]element.SummaryResponse),
+		Live:           make(map[int]live.Response),
+		Entries:        make(map[int]entry.Response),
+		EntryHistories: make(map[int]entry.HistoryResponse),
+		Transfers:      make(map[int][]entry.Transfer),
+		Picks:          make(map[int]map[int]entry.PicksResponse),
+		ClassicLeagues: make(map[int]league.Response),
+		H2HLeagues:     make(map[int]league.Response),
+		H2HMatches:     make(map[int][]league.Match),
+	}
+	res := &season.Bootstrap
+	res.TotalPlayers = 1000000
+	res.GameSettings = fpl.GameSetting{
+		SquadSquadplay:     11,
+		SquadSquadsize:     15,
+		SquadTeamLimit:     3,
+		SquadTotalSpend:    1000,
+		TransfersSellOnFee: 0.5,
+	}
+	for id := 1; id <= 4; id++ {
+		res.PlayerRoles = append(res.PlayerRoles, team.PlayerRoles{
+			ID:           id,
+			ShortName:    roleNames[id],
+			LongName:     roleNames[id],
+			SquadSelect:  SquadShape[id],
+			SquadMinPlay: rolePlay[id][0],
+			SquadMaxPlay: rolePlay[id][1],
+		})
+	}
+
+	// teams with strengths, stronger teams come first
+	for id := 1; id <= numTeams; id++ {
+		strength := 1350 - 10*id + r.Intn(20)
+		res.Teams = append(res.Teams, team.Team{
+			ID:                  id,
+			ShortName:           fmt.Sprintf("T%02d", id),
+			LongName:            fmt.Sprintf("Team %02d", id),
+			StrengthAttackHome:  strength + 30,
+			StrengthAttackAway:  strength - 20,
+			StrengthDefenceHome: strength + 20,
+			StrengthDefenceAway: strength - 30,
+		})
+	}
+
+	// players per team, the first of each role is a regular starter
+	playerID := 0
+	for _, t := range res.Teams {
+		for roleID := 1; roleID <= 4; roleID++ {
+			for i := 0; i < SquadShape[roleID]; i++ {
+				playerID++
+				cost := 40 + 5*(roleID-1) + r.Intn(20) + numTeams - t.ID
+				if i >= StarterShape[roleID] {
+					cost -= 5
+				}
+				res.Players = append(res.Players, team.Player{
+					ID:       playerID,
+					WebName:  fmt.Sprintf("%v-%v-%d", t.ShortName, roleNames[roleID], i+1),
+					TeamName: t.ShortName,
+					RoleName: roleNames[roleID],
+					TeamID:   t.ID,
+					RoleID:   roleID,
+					NowCost:  cost,
+					Status:   "a",
+				})
+			}
+		}
+	}
+
+	matches := roundRobin(r, numTeams, gameweeks, played)
+	for gw := 1; gw <= gameweeks; gw++ {
+		chipPlays := []fpl.ChipPlay{}
+		for i, chip := range []string{"bboost", "3xc", "freehit", "wildcard"} {
+			if gw > 1 && gw <= played {
+				chipPlays = append(chipPlays, fpl.ChipPlay{ChipName: chip, NumPlayed: res.TotalPlayers / 200 * ((gw*7+i*3)%5 + 1)})
+			}
+		}
+		res.Events = append(res.Events, fpl.Event{
+			ID:           gw,
+			Name:         fmt.Sprintf("Gameweek %d", gw),
+			DeadlineTime: SeasonStart.AddDate(0, 0, 7*(gw-1)).Add(-90 * time.Minute),
+			Finished:     gw <= played,
+			DataChecked:  gw <= played,
+			IsPrevious:   gw == played,
+			IsCurrent:    gw == played,
+			IsNext:       gw == played+1,
+			ChipPlays:    chipPlays,
+		})
+	}
+	fillSummaries(r, &season, matches)
+	season.Fixtures = seasonFixtures(matches)
+	return season
+}
+
+// roundRobin schedule with the circle method, home and away alternate every gameweek
+func roundRobin(r *rand.Rand, numTeams, gameweeks, played int) []match {
+	teams := make([]int, numTeams)
+	for i := range teams {
+		teams[i] = i + 1
+	}
+	matches := []match{}
+	for gw := 1; gw <= gameweeks; gw++ {
+		round := (gw - 1) % (numTeams - 1)
+		rotated := append([]int{teams[0]}, rotate(teams[1:], round)...)
+		for i := 0; i < numTeams/2; i++ {
+			home, away := rotated[i], rotated[numTeams-1-i]
+			if (gw+i)%2 == 1 {
+				home, away = away, home
+			}
+			m := match{
+				id:       len(matches) + 1,
+				event:    gw,
+				home:     home,
+				away:     away,
+				homeDiff: difficulty(away, numTeams),
+				awayDiff: difficulty(home, numTeams),
+				kickoff:  SeasonStart.AddDate(0, 0, 7*(gw-1)).Add(time.Duration(i%4) * 2 * time.Hour),
+				finished: gw <= played,
+			}
+			if m.finished {
+				m.homeScore = goals(r, home, away, numTeams, true)
+				m.awayScore = goals(r, away, home, numTeams, false)
+			}
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// rotate ids to the right by n
+func rotate(ids []int, n int) []int {
+	n %= len(ids)
+	return append(append([]int{}, ids[len(ids)-n:]...), ids[:len(ids)-n]...)
+}
+
+// difficulty of facing opponent, from 2 (weakest fifth) to 5 (strongest fifth)
+func difficulty(opponent, numTeams int) int {
+	return 5 - 4*(opponent-1)/numTeams
+}
+
+// goals scored by team against opponent, stronger (lower ID) teams score more
+func goals(r *rand.Rand, teamID, opponent, numTeams int, home bool) int {
+	expected := 1.3 + float64(opponent-teamID)/float64(numTeams)
+	if home {
+		expected += 0.2
+	}
+	scored := 0
+	for i := 0; i < 6; i++ {
+		if r.Float64() < expected/6 {
+			scored++
+		}
+	}
+	return scored
+}
+
+// fillSummaries of every player from matches, with fpl scoring rules
+func fillSummaries(r *rand.Rand, season *Season, matches []match) {
+	res := &season.Bootstrap
+	byTeam := make(map[int][]int)
+	for i, p := range res.Players {
+		byTeam[p.TeamID] = append(byTeam[p.TeamID], i)
+	}
+
+	histories := make(map[int][]element.History)
+	for mi, m := range matches {
+		if !m.finished {
+			continue
+		}
+		fixtureHistory := []element.History{}
+		for _, side := range []struct {
+			teamID, opponent, scored, conceded int
+			home                               bool
+		}{
+			{m.home, m.away, m.homeScore, m.awayScore, true},
+			{m.away, m.home, m.awayScore, m.homeScore, false},
+		} {
+			teamHistory := []element.History{}
+			for _, i := range byTeam[side.teamID] {
+				p := res.Players[i]
+				h := element.History{
+					PlayerID:   p.ID,
+					Fixture:    m.id,
+					Value:      p.NowCost,
+					OpponentID: side.opponent,
+					Round:      m.event,
+					WasHome:    side.home,
+					TeamHScore: m.homeScore,
+					TeamAScore: m.awayScore,
+				}
+				if starter(p, res.Players) && r.Float64() < 0.9 {
+					h.Minutes = 90
+				} else if !starter(p, res.Players) && p.RoleID != 1 && r.Float64() < 0.3 {
+					h.Minutes = 10 + r.Intn(30)
+				}
+				if h.Minutes > 0 {
+					h.GoalsConceded = side.conceded
+				}
+				teamHistory = append(teamHistory, h)
+			}
+			assignEvents(r, teamHistory, res.Players, side.scored)
+			for i := range teamHistory {
+				teamHistory[i].TotalPoints = points(teamHistory[i], res.Players[teamHistory[i].PlayerID-1].RoleID)
+			}
+			fixtureHistory = append(fixtureHistory, teamHistory...)
+		}
+		assignBonus(fixtureHistory)
+		matches[mi].stats = fixtureStats(m, fixtureHistory)
+		for _, h := range fixtureHistory {
+			histories[h.PlayerID] = append(histories[h.PlayerID], h)
+		}
+		addLive(season, m, fixtureHistory, res.Players)
+	}
+
+	for i, p := range res.Players {
+		summary := element.SummaryResponse{PlayerID: p.ID, PastMatches: histories[p.ID]}
+		for _, m := range matches {
+			if m.finished || (m.home != p.TeamID && m.away != p.TeamID) {
+				continue
+			}
+			f := element.Fixture{
+				FixtureID:  m.id,
+				Difficulty: m.homeDiff,
+				TeamH:      m.home,
+				TeamA:      m.away,
+				IsHome:     m.home == p.TeamID,
+				Gameweek:   fmt.Sprintf("Gameweek %d", m.event),
+			}
+			if !f.IsHome {
+				f.Difficulty = m.awayDiff
+			}
+			summary.Fixtures = append(summary.Fixtures, f)
+		}
+		season.Summaries[p.ID] = summary
+		fillPlayerTotals(&res.Players[i], summary.PastMatches)
+	}
+}
+
+// addLive elements of a finished match into its gameweek, summing doubles
+func addLive(season *Season, m match, fixtureHistory []element.History, players []team.Player) {
+	res := season.Live[m.event]
+	index := make(map[int]int, len(res.Elements))
+	for i, e := range res.Elements {
+		index[e.ID] = i
+	}
+	for _, h := range fixtureHistory {
+		roleID := players[h.PlayerID-1].RoleID
+		explain := live.Explain{Fixture: m.id}
+		if h.Minutes > 0 {
+			appearance := 1
+			if h.Minutes >= 60 {
+				appearance = 2
+			}
+			explain.Stats = append(explain.Stats, live.ExplainStat{Identifier: "minutes", Points: appearance, Value: h.Minutes})
+		}
+		for _, stat := range []struct {
+			identifier    string
+			value, points int
+		}{
+			{"goals_scored", h.GoalsScored, h.GoalsScored * map[int]int{1: 6, 2: 6, 3: 5, 4: 4}[roleID]},
+			{"assists", h.Assists, h.Assists * 3},
+			{"clean_sheets", h.CleanSheets, h.CleanSheets * map[int]int{1: 4, 2: 4, 3: 1, 4: 0}[roleID]},
+			{"saves", h.Saves, h.Saves / 3},
+			{"bonus", h.Bonus, h.Bonus},
+		} {
+			if stat.value > 0 {
+				explain.Stats = append(explain.Stats, live.ExplainStat{Identifier: stat.identifier, Points: stat.points, Value: stat.value})
+			}
+		}
+		if roleID <= 2 && h.GoalsConceded >= 2 {
+			explain.Stats = append(explain.Stats, live.ExplainStat{Identifier: "goals_conceded", Points: -h.GoalsConceded / 2, Value: h.GoalsConceded})
+		}
+
+		i, ok := index[h.PlayerID]
+		if !ok {
+			i = len(res.Elements)
+			index[h.PlayerID] = i
+			res.Elements = append(res.Elements, live.Element{ID: h.PlayerID})
+		}
+		e := &res.Elements[i]
+		e.Stats.Minutes += h.Minutes
+		e.Stats.GoalsScored += h.GoalsScored
+		e.Stats.Assists += h.Assists
+		e.Stats.CleanSheets += h.CleanSheets
+		e.Stats.GoalsConceded += h.GoalsConceded
+		e.Stats.Saves += h.Saves
+		e.Stats.Bonus += h.Bonus
+		e.Stats.Bps += h.Bps
+		e.Stats.TotalPoints += h.TotalPoints
+		e.Explain = append(e.Explain, explain)
+	}
+	season.Live[m.event] = res
+}
+
+// starter when p is among the first StarterShape players of its role in its team
+func starter(p team.Player, players []team.Player) bool {
+	for _, first := range players {
+		if first.TeamID == p.TeamID && first.RoleID == p.RoleID {
+			return p.ID-first.ID < StarterShape[p.RoleID]
+		}
+	}
+	return false
+}
+
+// assignEvents of goals, assists and saves to players who played, weighted by role
+func assignEvents(r *rand.Rand, teamHistory []element.History, players []team.Player, scored int) {
+	weights := map[int]int{1: 0, 2: 1, 3: 3, 4: 5}
+	pick := func(exclude int) int {
+		total := 0
+		for i, h := range teamHistory {
+			if h.Minutes > 0 && i != exclude {
+				total += weights[players[h.PlayerID-1].RoleID]
+			}
+		}
+		if total == 0 {
+			return -1
+		}
+		n := r.Intn(total)
+		for i, h := range teamHistory {
+			if h.Minutes <= 0 || i == exclude {
+				continue
+			}
+			n -= weights[players[h.PlayerID-1].RoleID]
+			if n < 0 {
+				return i
+			}
+		}
+		return -1
+	}
+	for g := 0; g < scored; g++ {
+		scorer := pick(-1)
+		if scorer < 0 {
+			return
+		}
+		teamHistory[scorer].GoalsScored++
+		if assister := pick(scorer); assister >= 0 && r.Float64() < 0.7 {
+			teamHistory[assister].Assists++
+		}
+	}
+	for i, h := range teamHistory {
+		if h.Minutes > 0 && players[h.PlayerID-1].RoleID == 1 {
+			teamHistory[i].Saves = r.Intn(6)
+		}
+		if h.Minutes >= 60 && h.GoalsConceded == 0 {
+			teamHistory[i].CleanSheets = 1
+		}
+	}
+}
+
+// points by fpl scoring rules, excluding bonus
+func points(h element.History, roleID int) int {
+	if h.Minutes <= 0 {
+		return 0
+	}
+	goalPoints := map[int]int{1: 6, 2: 6, 3: 5, 4: 4}
+	cleanSheetPoints := map[int]int{1: 4, 2: 4, 3: 1, 4: 0}
+	total := 1
+	if h.Minutes >= 60 {
+		total = 2
+	}
+	total += h.GoalsScored*goalPoints[roleID] + h.Assists*3 + h.CleanSheets*cleanSheetPoints[roleID]
+	if roleID <= 2 {
+		total -= h.GoalsConceded / 2
+	}
+	return total + h.Saves/3
+}
+
+// assignBonus by bps with the official allocation, bps derived from points and minutes
+func assignBonus(fixtureHistory []element.History) {
+	scores := make([]bonus.Score, len(fixtureHistory))
+	for i, h := range fixtureHistory {
+		fixtureHistory[i].Bps = 3*h.TotalPoints + h.Minutes/10 + 2*h.Saves
+		scores[i] = bonus.Score{Element: h.PlayerID, Bps: fixtureHistory[i].Bps, Minutes: h.Minutes}
+	}
+	id2Bonus := bonus.Allocate(scores)
+	for i, h := range fixtureHistory {
+		fixtureHistory[i].Bonus = id2Bonus[h.PlayerID]
+		fixtureHistory[i].TotalPoints += id2Bonus[h.PlayerID]
+	}
+}
+
+// fixtureStats of bps and bonus, as listed by api/fixtures/ once bonus is confirmed
+func fixtureStats(m match, fixtureHistory []element.History) []fixtures.Stat {
+	bps := fixtures.Stat{Identifier: "bps"}
+	bonusStat := fixtures.Stat{Identifier: "bonus"}
+	for _, h := range fixtureHistory {
+		side := &bps.A
+		bonusSide := &bonusStat.A
+		if h.WasHome {
+			side, bonusSide = &bps.H, &bonusStat.H
+		}
+		if h.Minutes > 0 {
+			*side = append(*side, fixtures.StatValue{Element: h.PlayerID, Value: h.Bps})
+		}
+		if h.Bonus > 0 {
+			*bonusSide = append(*bonusSide, fixtures.StatValue{Element: h.PlayerID, Value: h.Bonus})
+		}
+	}
+	return []fixtures.Stat{bps, bonusStat}
+}
+
+// fillPlayerTotals of bootstrap-static from past matches
+func fillPlayerTotals(p *team.Player, pastMatches []element.History) {
+	played := 0
+	recent, recentCount := 0, 0
+	for i, h := range pastMatches {
+		p.TotalPoints += h.TotalPoints
+		p.Minutes += h.Minutes
+		if h.Minutes > 0 {
+			played++
+		}
+		if i >= len(pastMatches)-4 {
+			recent += h.TotalPoints
+			recentCount++
+		}
+	}
+	form := 0.0
+	if recentCount > 0 {
+		form = float64(recent) / float64(recentCount)
+	}
+	pointsPerGame := 0.0
+	if played > 0 {
+		pointsPerGame = float64(p.TotalPoints) / float64(played)
+	}
+	price := float64(p.NowCost) / 10
+	p.Form = fmt.Sprintf("%.1f", form)
+	p.PointsPerGame = fmt.Sprintf("%.1f", pointsPerGame)
+	p.ValueForm = fmt.Sprintf("%.1f", form/price)
+	p.ValueSeason = fmt.Sprintf("%.1f", float64(p.TotalPoints)/price)
+	p.IctIndex = fmt.Sprintf("%.1f", float64(p.Minutes)/90*2.5)
+	p.EpThis = p.Form
+	p.EpNext = p.Form
+	// in form players are owned and bought, the rest spread by ID
+	p.SelectedByPercent = fmt.Sprintf("%.1f", 1+4*form+float64(p.ID*37%100)/10)
+	p.TransfersInEvent = int(form*1000) + p.ID*131%5000
+	p.TransfersOutEvent = p.ID * 977 % 5000
+}
+
+// seasonFixtures in the shape of api/fixtures/
+func seasonFixtures(matches []match) []fixtures.Fixture {
+	out := make([]fixtures.Fixture, 0, len(matches))
+	for _, m := range matches {
+		f := fixtures.Fixture{
+			ID:              m.id,
+			Code:            m.id,
+			Event:           m.event,
+			KickoffTime:     m.kickoff,
+			TeamH:           m.home,
+			TeamA:           m.away,
+			TeamHDifficulty: m.homeDiff,
+			TeamADifficulty: m.awayDiff,
+			Started:         m.finished,
+			Finished:        m.finished,
+			Stats:           m.stats,
+		}
+		if m.finished {
+			f.Minutes = 90
+			f.FinishedProvisional = true
+			f.TeamHScore = m.homeScore
+			f.TeamAScore = m.awayScore
+		}
+		out = append(out, f)
+	}
+	return out
+}
@@ -0,0 +1,368 @@
+// Package league provides structures and methods to crawl classic and h2h league standings
+package league
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+)
+
+// RawClassicEndpoint and RawH2HEndpoint to get a page of league standings,
+// RawH2HMatchesEndpoint a page of an h2h league's matches
+var (
+	RawClassicEndpoint    = "https://fantasy.premierleague.com/api/leagues-classic/%d/standings/?page_standings=%d"
+	RawH2HEndpoint        = "https://fantasy.premierleague.com/api/leagues-h2h/%d/standings/?page_standings=%d"
+	RawH2HMatchesEndpoint = "https://fantasy.premierleague.com/api/leagues-h2h-matches/league/%d/?page=%d"
+)
+
+// DefaultConcurrency of picks requests when League.Concurrency is not set
+const DefaultConcurrency = 8
+
+// League for league standings and its members' picks.
+// Client.Endpoint is RawClassicEndpoint or RawH2HEndpoint, EntryClient.Endpoint is entry.RawEndpoint
+// and MatchesClient.Endpoint RawH2HMatchesEndpoint, used by h2h leagues only.
+type League struct {
+	Client         client.GenericClient
+	EntryClient    client.GenericClient
+	MatchesClient  client.GenericClient
+	Registry       *registry.Registry
+	ID             int
+	Gameweeks      []int
+	Concurrency    int
+	Res            Response
+	Members        []Standing
+	Matches        []Match
+	Entry2Gw2Picks map[int]map[int]entry.PicksResponse
+	FailedEntries  []int
+}
+
+// Response from api/leagues-classic/{id}/standings/ or api/leagues-h2h/{id}/standings/
+type Response struct {
+	League    Info      `json:"league"`
+	Standings Standings `json:"standings"`
+}
+
+// Info ... to skip go-lint
+type Info struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Created     time.Time `json:"created"`
+	Scoring     string    `json:"scoring"`
+	StartEvent  int       `json:"start_event"`
+	AdminEntry  int       `json:"admin_entry"`
+	LeagueType  string    `json:"league_type"`
+	Closed      bool      `json:"closed"`
+	MaxEntries  int       `json:"max_entries"`
+	CodePrivacy string    `json:"code_privacy"`
+}
+
+// Standings ... to skip go-lint
+type Standings struct {
+	HasNext bool       `json:"has_next"`
+	Page    int        `json:"page"`
+	Results []Standing `json:"results"`
+}
+
+// Standing ... to skip go-lint, Matches* and PointsFor are h2h only
+type Standing struct {
+	ID            int    `json:"id"`
+	Entry         int    `json:"entry"`
+	EntryName     string `json:"entry_name"`
+	PlayerName    string `json:"player_name"`
+	EventTotal    int    `json:"event_total"`
+	Total         int    `json:"total"`
+	Rank          int    `json:"rank"`
+	LastRank      int    `json:"last_rank"`
+	RankSort      int    `json:"rank_sort"`
+	MatchesPlayed int    `json:"matches_played"`
+	MatchesWon    int    `json:"matches_won"`
+	MatchesDrawn  int    `json:"matches_drawn"`
+	MatchesLost   int    `json:"matches_lost"`
+	PointsFor     int    `json:"points_for"`
+}
+
+// MatchesResponse from api/leagues-h2h-matches/league/{id}/
+type MatchesResponse struct {
+	HasNext bool    `json:"has_next"`
+	Page    int     `json:"page"`
+	Results []Match `json:"results"`
+}
+
+// Match of two h2h entries in a gameweek, Entry2Entry is 0 on a bye
+type Match struct {
+	ID           int  `json:"id"`
+	Event        int  `json:"event"`
+	Entry1Entry  int  `json:"entry_1_entry"`
+	Entry1Points int  `json:"entry_1_points"`
+	Entry1Win    int  `json:"entry_1_win"`
+	Entry1Draw   int  `json:"entry_1_draw"`
+	Entry1Loss   int  `json:"entry_1_loss"`
+	Entry2Entry  int  `json:"entry_2_entry"`
+	Entry2Points int  `json:"entry_2_points"`
+	Entry2Win    int  `json:"entry_2_win"`
+	Entry2Draw   int  `json:"entry_2_draw"`
+	Entry2Loss   int  `json:"entry_2_loss"`
+	IsKnockout   bool `json:"is_knockout"`
+	IsBye        bool `json:"is_bye"`
+}
+
+// H2HScoring of Info.Scoring in head-to-head leagues, "c" being classic
+const H2HScoring = "h"
+
+// RankRow of a member in a gameweek, Movement is positive when climbing.
+// MatchPoints and PointsFor are h2h only.
+type RankRow struct {
+	League      int
+	Gameweek    int
+	Rank        int
+	LastRank    int
+	Movement    int
+	Entry       int
+	EntryName   string
+	PlayerName  string
+	GwPoints    int
+	TotalPoints int
+	Transfers   int
+	HitPoints   int
+	ActiveChip  string
+	Captain     string
+	MatchPoints int `csv:",omitempty"`
+	PointsFor   int `csv:",omitempty"`
+}
+
+// picksJob of one member in one gameweek
+type picksJob struct {
+	entryID  int
+	gameweek int
+}
+
+// GetLeagueToCsv standings, members' picks of Gameweeks and one rank table per gameweek
+func (l *League) GetLeagueToCsv(ctx context.Context) error {
+	start := time.Now()
+	defer func() {
+		log.Printf("Took %v to crawl league %d\n", time.Since(start), l.ID)
+	}()
+
+	if err := l.FetchStandings(ctx); err != nil {
+		return err
+	}
+	if l.Res.League.Scoring == H2HScoring {
+		if err := l.FetchMatches(ctx); err != nil {
+			return err
+		}
+	}
+	l.FetchPicks(ctx)
+
+	if err := csv.StructSlice(l.Members, fmt.Sprintf("fpl-leagues/%d-standings", l.ID)); err != nil {
+		return err
+	}
+	for gw, rows := range l.RankTables() {
+		if err := csv.StructSlice(rows, fmt.Sprintf("fpl-leagues/%d-gw%d", l.ID, gw)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FetchStandings of every page into Res and Members
+func (l *League) FetchStandings(ctx context.Context) error {
+	l.Members = nil
+	for page := 1; ; page++ {
+		localClient := l.Client
+		localClient.Endpoint = fmt.Sprintf(l.Client.Endpoint, l.ID, page)
+		res := Response{}
+		if err := localClient.FetchJSON(ctx, &res); err != nil {
+			return err
+		}
+		l.Res = res
+		l.Members = append(l.Members, res.Standings.Results...)
+		if !res.Standings.HasNext || len(res.Standings.Results) == 0 {
+			return nil
+		}
+	}
+}
+
+// FetchMatches of every page into Matches
+func (l *League) FetchMatches(ctx context.Context) error {
+	l.Matches = nil
+	for page := 1; ; page++ {
+		localClient := l.MatchesClient
+		localClient.Endpoint = fmt.Sprintf(l.MatchesClient.Endpoint, l.ID, page)
+		res := MatchesResponse{}
+		if err := localClient.FetchJSON(ctx, &res); err != nil {
+			return err
+		}
+		l.Matches = append(l.Matches, res.Results...)
+		if !res.HasNext || len(res.Results) == 0 {
+			return nil
+		}
+	}
+}
+
+// FetchPicks of every member in Gameweeks concurrently, failures end up in FailedEntries
+// as do members left unsent once ctx is done
+func (l *League) FetchPicks(ctx context.Context) {
+	concurrency := l.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	total := len(l.Members) * len(l.Gameweeks)
+
+	type picksResult struct {
+		job   picksJob
+		picks entry.PicksResponse
+	}
+	jobQueue := make(chan picksJob)
+	resultQueue := make(chan picksResult, total)
+	failedQueue := make(chan int, total)
+	// use WaitGroup to bound the goroutines into a worker pool
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func(wg *sync.WaitGroup) {
+			defer wg.Done()
+			for job := range jobQueue {
+				// new instance for each local Entry in each goroutine
+				localEntry := entry.Entry{Client: l.EntryClient, ID: job.entryID}
+				picks, err := localEntry.FetchPicks(ctx, job.gameweek)
+				if err != nil {
+					log.Printf("error on entry %d GW%d: %+v\n", job.entryID, job.gameweek, err)
+					failedQueue <- job.entryID
+					continue
+				}
+				resultQueue <- picksResult{job: job, picks: picks}
+			}
+		}(&wg)
+	}
+	for _, member := range l.Members {
+		for _, gw := range l.Gameweeks {
+			select {
+			case jobQueue <- picksJob{entryID: member.Entry, gameweek: gw}:
+			case <-ctx.Done():
+				failedQueue <- member.Entry
+			}
+		}
+	}
+	close(jobQueue)
+	wg.Wait()
+	close(resultQueue)
+	close(failedQueue)
+
+	l.Entry2Gw2Picks = make(map[int]map[int]entry.PicksResponse)
+	for result := range resultQueue {
+		if _, ok := l.Entry2Gw2Picks[result.job.entryID]; !ok {
+			l.Entry2Gw2Picks[result.job.entryID] = make(map[int]entry.PicksResponse)
+		}
+		l.Entry2Gw2Picks[result.job.entryID][result.job.gameweek] = result.picks
+	}
+	failed := make(map[int]bool)
+	l.FailedEntries = nil
+	for entryID := range failedQueue {
+		if !failed[entryID] {
+			failed[entryID] = true
+			l.FailedEntries = append(l.FailedEntries, entryID)
+		}
+	}
+	sort.Ints(l.FailedEntries)
+	if len(l.FailedEntries) > 0 {
+		log.Printf("%d of %d league members have missing picks: %+v\n", len(l.FailedEntries), len(l.Members), l.FailedEntries)
+	}
+}
+
+// RankTables per gameweek, members of a classic league ranked by total points with ties
+// sharing a rank. An h2h league is ranked by match points, then points for, then total
+// points, both summed over its Matches up to the gameweek.
+func (l *League) RankTables() map[int][]RankRow {
+	gameweeks := append([]int{}, l.Gameweeks...)
+	sort.Ints(gameweeks)
+	isH2H := l.Res.League.Scoring == H2HScoring
+	less := func(a, b RankRow) bool { return a.TotalPoints > b.TotalPoints }
+	if isH2H {
+		less = func(a, b RankRow) bool {
+			if a.MatchPoints != b.MatchPoints {
+				return a.MatchPoints > b.MatchPoints
+			}
+			if a.PointsFor != b.PointsFor {
+				return a.PointsFor > b.PointsFor
+			}
+			return a.TotalPoints > b.TotalPoints
+		}
+	}
+
+	tables := make(map[int][]RankRow)
+	lastRank := make(map[int]int)
+	for _, gw := range gameweeks {
+		rows := []RankRow{}
+		for _, member := range l.Members {
+			picks, ok := l.Entry2Gw2Picks[member.Entry][gw]
+			if !ok {
+				continue
+			}
+			row := RankRow{
+				League:      l.ID,
+				Gameweek:    gw,
+				Entry:       member.Entry,
+				EntryName:   member.EntryName,
+				PlayerName:  member.PlayerName,
+				GwPoints:    picks.EntryHistory.Points,
+				TotalPoints: picks.EntryHistory.TotalPoints,
+				Transfers:   picks.EntryHistory.EventTransfers,
+				HitPoints:   picks.EntryHistory.EventTransfersCost,
+				ActiveChip:  picks.ActiveChip,
+			}
+			if isH2H {
+				row.MatchPoints, row.PointsFor = l.h2hPoints(member.Entry, gw)
+			}
+			for _, pick := range picks.Picks {
+				if pick.IsCaptain {
+					row.Captain = l.Registry.PlayerName(pick.Element)
+				}
+			}
+			rows = append(rows, row)
+		}
+		sort.SliceStable(rows, func(i, j int) bool { return less(rows[i], rows[j]) })
+		for i := range rows {
+			rows[i].Rank = i + 1
+			if i > 0 && !less(rows[i-1], rows[i]) {
+				rows[i].Rank = rows[i-1].Rank
+			}
+			rows[i].LastRank = lastRank[rows[i].Entry]
+			if rows[i].LastRank > 0 {
+				rows[i].Movement = rows[i].LastRank - rows[i].Rank
+			}
+		}
+		for _, row := range rows {
+			lastRank[row.Entry] = row.Rank
+		}
+		if len(rows) > 0 {
+			tables[gw] = rows
+		}
+	}
+	return tables
+}
+
+// h2hPoints of entryID over Matches up to gameweek gw, 3 a win and 1 a draw
+func (l *League) h2hPoints(entryID, gw int) (matchPoints, pointsFor int) {
+	for _, m := range l.Matches {
+		if m.Event > gw {
+			continue
+		}
+		switch entryID {
+		case m.Entry1Entry:
+			matchPoints += 3*m.Entry1Win + m.Entry1Draw
+			pointsFor += m.Entry1Points
+		case m.Entry2Entry:
+			matchPoints += 3*m.Entry2Win + m.Entry2Draw
+			pointsFor += m.Entry2Points
+		}
+	}
+	return matchPoints, pointsFor
+}
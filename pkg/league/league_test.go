@@ -0,0 +1,213 @@
+package league_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpltest"
+	"github.com/jadugnap/golang-fpl-101/pkg/league"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+)
+
+func TestGetLeagueToCsv(t *testing.T) {
+	season := fpltest.NewSeason(1, 6, 5, 3)
+	entryIDs := []int{}
+	for id := 1; id <= 55; id++ {
+		if err := season.AddEntry(id, fmt.Sprintf("entry %d", id), int64(id)); err != nil {
+			t.Fatal(err)
+		}
+		entryIDs = append(entryIDs, id)
+	}
+	season.AddClassicLeague(10, "classic", entryIDs)
+	season.AddClassicLeague(11, "small", entryIDs[:3])
+	season.AddH2HLeague(20, "h2h", entryIDs[:5])
+	srv := fpltest.NewServer(season)
+	defer srv.Close()
+	res := season.Bootstrap
+	r := registry.New(res.Players, res.Teams, res.PlayerRoles)
+
+	tests := []struct {
+		name          string
+		endpoint      string
+		id            int
+		faults        fpltest.Faults
+		wantMembers   int
+		wantPages     int
+		wantFailed    []int
+		wantGameweeks []int
+		wantRows      int
+	}{
+		{
+			name:          "classic over two pages",
+			endpoint:      srv.ClassicLeagueEndpoint(),
+			id:            10,
+			wantMembers:   55,
+			wantPages:     2,
+			wantGameweeks: []int{1, 2, 3},
+			wantRows:      55,
+		},
+		{
+			name:          "classic with missing picks",
+			endpoint:      srv.ClassicLeagueEndpoint(),
+			id:            11,
+			faults:        fpltest.Faults{Paths: []string{"/entry/2/event/"}, FailFirst: 10, FailStatus: http.StatusNotFound},
+			wantMembers:   3,
+			wantPages:     1,
+			wantFailed:    []int{2},
+			wantGameweeks: []int{1, 2, 3},
+			wantRows:      2,
+		},
+		{
+			name:          "h2h per gameweek",
+			endpoint:      srv.H2HLeagueEndpoint(),
+			id:            20,
+			wantMembers:   5,
+			wantPages:     1,
+			wantGameweeks: []int{1, 2, 3},
+			wantRows:      5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csv.Dir = t.TempDir()
+			defer func() { csv.Dir = "csv_out" }()
+			srv.SetFaults(tt.faults)
+
+			retry := client.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond}
+			l := league.League{
+				Client:        client.GenericClient{Endpoint: tt.endpoint, Retry: retry},
+				EntryClient:   client.GenericClient{Endpoint: srv.EntryEndpoint(), Retry: retry},
+				MatchesClient: client.GenericClient{Endpoint: srv.H2HMatchesEndpoint(), Retry: retry},
+				Registry:      r,
+				ID:            tt.id,
+				Gameweeks:     []int{3, 1, 2},
+				Concurrency:   4,
+			}
+			if err := l.GetLeagueToCsv(context.Background()); err != nil {
+				t.Fatalf("GetLeagueToCsv() error = %v", err)
+			}
+			if len(l.Members) != tt.wantMembers {
+				t.Errorf("%d members, want %d", len(l.Members), tt.wantMembers)
+			}
+			if got := srv.Hits(fmt.Sprintf("/%d/standings/", tt.id)); got != tt.wantPages {
+				t.Errorf("%d standings pages fetched, want %d", got, tt.wantPages)
+			}
+			if !reflect.DeepEqual(l.FailedEntries, tt.wantFailed) {
+				t.Errorf("FailedEntries = %v, want %v", l.FailedEntries, tt.wantFailed)
+			}
+
+			tables := l.RankTables()
+			gameweeks := []int{}
+			for gw, rows := range tables {
+				gameweeks = append(gameweeks, gw)
+				if len(rows) != tt.wantRows {
+					t.Errorf("GW%d table of %d rows, want %d", gw, len(rows), tt.wantRows)
+				}
+				checkRanked(t, gw, rows, l.Res.League.Scoring == league.H2HScoring)
+				checkMovement(t, gw, rows, tables[gw-1])
+			}
+			if tt.id == 20 {
+				// the last table adds up to the standings
+				member2Total := make(map[int]int)
+				for _, member := range l.Members {
+					member2Total[member.Entry] = member.Total
+				}
+				for _, row := range tables[3] {
+					if row.MatchPoints != member2Total[row.Entry] {
+						t.Errorf("GW3 entry %d of %d match points, want %d", row.Entry, row.MatchPoints, member2Total[row.Entry])
+					}
+				}
+			}
+			sort.Ints(gameweeks)
+			if !reflect.DeepEqual(gameweeks, tt.wantGameweeks) {
+				t.Errorf("tables of %v, want %v", gameweeks, tt.wantGameweeks)
+			}
+			files, _ := filepath.Glob(filepath.Join(csv.Dir, "fpl-leagues", "*.csv"))
+			if want := 1 + len(tt.wantGameweeks); len(files) != want {
+				t.Errorf("%d csv files, want %d", len(files), want)
+			}
+		})
+	}
+}
+
+// checkMovement of rows against the last gameweek's, none in the first one
+func checkMovement(t *testing.T, gw int, rows, lastRows []league.RankRow) {
+	t.Helper()
+	entry2Rank := make(map[int]int, len(lastRows))
+	for _, row := range lastRows {
+		entry2Rank[row.Entry] = row.Rank
+	}
+	for _, row := range rows {
+		if row.LastRank != entry2Rank[row.Entry] || (row.LastRank > 0 && row.Movement != row.LastRank-row.Rank) {
+			t.Errorf("GW%d entry %d LastRank %d Movement %d, want LastRank %d", gw, row.Entry, row.LastRank, row.Movement, entry2Rank[row.Entry])
+		}
+	}
+}
+
+func TestFetchPicksCancelled(t *testing.T) {
+	season := fpltest.NewSeason(1, 6, 3, 2)
+	for id := 1; id <= 3; id++ {
+		if err := season.AddEntry(id, fmt.Sprintf("entry %d", id), int64(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := fpltest.NewServer(season)
+	defer srv.Close()
+	l := league.League{
+		EntryClient: client.GenericClient{Endpoint: srv.EntryEndpoint()},
+		Members:     []league.Standing{{Entry: 1}, {Entry: 2}, {Entry: 3}},
+		Gameweeks:   []int{1, 2},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.FetchPicks(ctx)
+	// whether sent or not, no picks come back
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(l.FailedEntries, want) {
+		t.Errorf("FailedEntries = %v, want %v", l.FailedEntries, want)
+	}
+}
+
+// checkRanked rows of gw, by match points then points for then total points in h2h
+func checkRanked(t *testing.T, gw int, rows []league.RankRow, isH2H bool) {
+	t.Helper()
+	key := func(row league.RankRow) [3]int {
+		if !isH2H {
+			return [3]int{row.TotalPoints}
+		}
+		return [3]int{row.MatchPoints, row.PointsFor, row.TotalPoints}
+	}
+	for i, row := range rows {
+		if row.Captain == "" {
+			t.Errorf("GW%d entry %d without captain", gw, row.Entry)
+		}
+		if i == 0 {
+			if row.Rank != 1 {
+				t.Errorf("GW%d first rank = %d, want 1", gw, row.Rank)
+			}
+			continue
+		}
+		prev, cur := key(rows[i-1]), key(row)
+		switch {
+		case cur == prev && row.Rank != rows[i-1].Rank:
+			t.Errorf("GW%d tie %v ranked %d and %d", gw, cur, rows[i-1].Rank, row.Rank)
+		case cur != prev && row.Rank != i+1:
+			t.Errorf("GW%d row %d ranked %d, want %d", gw, i, row.Rank, i+1)
+		}
+		for k := range cur {
+			if cur[k] != prev[k] {
+				if cur[k] > prev[k] {
+					t.Errorf("GW%d %v ranked below %v", gw, cur, prev)
+				}
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Package live provides structures and methods to manage api/event/{gw}/live
+package live
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/bonus"
+	"github.com/jadugnap/golang-fpl-101/pkg/client"
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// RawEndpoint to get live gameweek response
+var (
+	RawEndpoint = "https://fantasy.premierleague.com/api/event/%d/live/"
+)
+
+// Live for live gameweek information
+type Live struct {
+	Client      client.GenericClient
+	Registry    *registry.Registry
+	Gameweek    int
+	Team2Player map[string][]team.Player
+	Fixtures    *fixtures.Fixtures
+	Res         Response
+	ID2Element  map[int]Element
+	// ProvisionalBonus by player of fixtures whose bonus is not confirmed yet
+	ProvisionalBonus map[int]int
+}
+
+// Response from api/event/{gw}/live/
+type Response struct {
+	Elements []Element `json:"elements"`
+}
+
+// Element ... to skip go-lint
+type Element struct {
+	ID      int       `json:"id"`
+	Stats   Stats     `json:"stats"`
+	Explain []Explain `json:"explain"`
+}
+
+// Stats ... to skip go-lint
+type Stats struct {
+	Minutes         int    `json:"minutes"`
+	GoalsScored     int    `json:"goals_scored"`
+	Assists         int    `json:"assists"`
+	CleanSheets     int    `json:"clean_sheets"`
+	GoalsConceded   int    `json:"goals_conceded"`
+	OwnGoals        int    `json:"own_goals"`
+	PenaltiesSaved  int    `json:"penalties_saved"`
+	PenaltiesMissed int    `json:"penalties_missed"`
+	YellowCards     int    `json:"yellow_cards"`
+	RedCards        int    `json:"red_cards"`
+	Saves           int    `json:"saves"`
+	Bonus           int    `json:"bonus"`
+	Bps             int    `json:"bps"`
+	Influence       string `json:"influence"`
+	Creativity      string `json:"creativity"`
+	Threat          string `json:"threat"`
+	IctIndex        string `json:"ict_index"`
+	TotalPoints     int    `json:"total_points"`
+	InDreamteam     bool   `json:"in_dreamteam"`
+}
+
+// Explain points of one fixture
+type Explain struct {
+	Fixture int           `json:"fixture"`
+	Stats   []ExplainStat `json:"stats"`
+}
+
+// ExplainStat ... to skip go-lint
+type ExplainStat struct {
+	Identifier string `json:"identifier"`
+	Points     int    `json:"points"`
+	Value      int    `json:"value"`
+}
+
+// PlayerRow of a live Element for csv.StructSlice, LivePoints include provisional bonus
+type PlayerRow struct {
+	ID               int
+	WebName          string
+	Team             string
+	Minutes          int
+	GoalsScored      int
+	Assists          int
+	Bonus            int
+	ProvisionalBonus int
+	Bps              int
+	TotalPoints      int
+	LivePoints       int
+	Explain          string
+}
+
+// TeamTotal of live points per team, grouped like FPL.Team2Player, TotalPoints include provisional bonus
+type TeamTotal struct {
+	Team             string
+	PlayerCount      int
+	Minutes          int
+	GoalsScored      int
+	Assists          int
+	Bonus            int
+	ProvisionalBonus int
+	TotalPoints      int
+}
+
+// GetLiveToCsv from api/event/{gw}/live/, Fixtures are refreshed too when set
+func (l *Live) GetLiveToCsv(ctx context.Context) error {
+	start := time.Now()
+	defer func() {
+		log.Printf("Took %v to GetResponse from %v\n", time.Since(start), l.Client.Endpoint)
+	}()
+
+	localClient := l.Client
+	localClient.Endpoint = fmt.Sprintf(l.Client.Endpoint, l.Gameweek)
+	res := Response{}
+	if err := localClient.FetchJSON(ctx, &res); err != nil {
+		return err
+	}
+	if l.Fixtures != nil {
+		if err := l.Fixtures.Fetch(ctx); err != nil {
+			return err
+		}
+	}
+	l.Res = res
+	l.ID2Element = make(map[int]Element, len(res.Elements))
+	for _, e := range res.Elements {
+		l.ID2Element[e.ID] = e
+	}
+	l.fillProvisionalBonus()
+
+	prefix := fmt.Sprintf("fpl-live/gw%d", l.Gameweek)
+	if err := csv.StructSlice(l.PlayerRows(), prefix+"-players"); err != nil {
+		return err
+	}
+	return csv.StructSlice(l.TeamTotals(), prefix+"-teams")
+}
+
+// Poll GetLiveToCsv every interval until ctx is done, onUpdate after every successful fetch
+func (l *Live) Poll(ctx context.Context, interval time.Duration, onUpdate func(*Live)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := l.GetLiveToCsv(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Println("error GetLiveToCsv():", err)
+		} else if onUpdate != nil {
+			onUpdate(l)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Points of player id including provisional bonus, 0 when absent from the live response
+func (l *Live) Points(id int) int {
+	return l.ID2Element[id].Stats.TotalPoints + l.ProvisionalBonus[id]
+}
+
+// fillProvisionalBonus of every fixture whose bonus is not confirmed yet, from fixture
+// bps stats when Fixtures are set, otherwise from elements playing a single fixture
+func (l *Live) fillProvisionalBonus() {
+	fixture2Scores := make(map[int][]bonus.Score)
+	confirmed := make(map[int]bool)
+	for _, e := range l.Res.Elements {
+		for _, explain := range e.Explain {
+			for _, stat := range explain.Stats {
+				if stat.Identifier == "bonus" && stat.Value > 0 {
+					confirmed[explain.Fixture] = true
+				}
+			}
+		}
+		if len(e.Explain) == 1 {
+			fixtureID := e.Explain[0].Fixture
+			fixture2Scores[fixtureID] = append(fixture2Scores[fixtureID], bonus.Score{
+				Element: e.ID,
+				Bps:     e.Stats.Bps,
+				Minutes: e.Stats.Minutes,
+			})
+		}
+	}
+	if l.Fixtures != nil {
+		for _, f := range l.Fixtures.Res {
+			if f.Event != l.Gameweek || !f.Started {
+				continue
+			}
+			scores, ok := bonus.FromFixture(f)
+			confirmed[f.ID] = confirmed[f.ID] || ok
+			if len(scores) > 0 {
+				fixture2Scores[f.ID] = scores
+			}
+		}
+	}
+
+	l.ProvisionalBonus = make(map[int]int)
+	for fixtureID, scores := range fixture2Scores {
+		if confirmed[fixtureID] {
+			continue
+		}
+		for id, b := range bonus.Allocate(scores) {
+			l.ProvisionalBonus[id] += b
+		}
+	}
+}
+
+// PlayerRows of every live element, ordered by ID
+func (l *Live) PlayerRows() []PlayerRow {
+	id2Team := make(map[int]string)
+	for teamName, players := range l.Team2Player {
+		for _, p := range players {
+			id2Team[p.ID] = teamName
+		}
+	}
+
+	rows := make([]PlayerRow, 0, len(l.Res.Elements))
+	for _, e := range l.Res.Elements {
+		explained := []string{}
+		for _, explain := range e.Explain {
+			for _, stat := range explain.Stats {
+				explained = append(explained, fmt.Sprintf("%v:%d", stat.Identifier, stat.Points))
+			}
+		}
+		rows = append(rows, PlayerRow{
+			ID:               e.ID,
+			WebName:          l.Registry.PlayerName(e.ID),
+			Team:             id2Team[e.ID],
+			Minutes:          e.Stats.Minutes,
+			GoalsScored:      e.Stats.GoalsScored,
+			Assists:          e.Stats.Assists,
+			Bonus:            e.Stats.Bonus,
+			ProvisionalBonus: l.ProvisionalBonus[e.ID],
+			Bps:              e.Stats.Bps,
+			TotalPoints:      e.Stats.TotalPoints,
+			LivePoints:       l.Points(e.ID),
+			Explain:          strings.Join(explained, " "),
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+	return rows
+}
+
+// TeamTotals of live points per team in Team2Player, ordered by total points
+func (l *Live) TeamTotals() []TeamTotal {
+	totals := make([]TeamTotal, 0, len(l.Team2Player))
+	for teamName, players := range l.Team2Player {
+		total := TeamTotal{Team: teamName}
+		for _, p := range players {
+			e, ok := l.ID2Element[p.ID]
+			if !ok || e.Stats.Minutes <= 0 {
+				continue
+			}
+			total.PlayerCount++
+			total.Minutes += e.Stats.Minutes
+			total.GoalsScored += e.Stats.GoalsScored
+			total.Assists += e.Stats.Assists
+			total.Bonus += e.Stats.Bonus
+			total.ProvisionalBonus += l.ProvisionalBonus[p.ID]
+			total.TotalPoints += l.Points(p.ID)
+		}
+		totals = append(totals, total)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].TotalPoints != totals[j].TotalPoints {
+			return totals[i].TotalPoints > totals[j].TotalPoints
+		}
+		return totals[i].Team < totals[j].Team
+	})
+	return totals
+}
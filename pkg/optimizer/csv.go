@@ -0,0 +1,49 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+)
+
+// Row of a Squad pick for csv.StructSlice, Position 1-11 for the XI then the bench order
+type Row struct {
+	Position int
+	Player   string
+	Team     string
+	Role     string
+	Cost     int
+	Score    float64
+	Starter  bool
+}
+
+// SquadToCsv best squad of f's players by column under f's game settings
+func SquadToCsv(ctx context.Context, f *fpl.FPL, column string, benchWeight float64) error {
+	candidates, err := Candidates(f.Res.Players, column)
+	if err != nil {
+		return err
+	}
+	o := Optimizer{
+		Rules:       NewRules(f.Res.GameSettings, f.Res.PlayerRoles),
+		BenchWeight: benchWeight,
+	}
+	sq, err := o.Solve(ctx, candidates)
+	if err != nil {
+		return err
+	}
+	rows := make([]Row, len(sq.Picks))
+	for i, p := range sq.Picks {
+		rows[i] = Row{
+			Position: i + 1,
+			Player:   f.Registry.PlayerName(p.ID),
+			Team:     f.Registry.TeamName(p.TeamID),
+			Role:     f.Registry.PositionName(p.RoleID),
+			Cost:     p.Cost,
+			Score:    p.Score,
+			Starter:  p.Starter,
+		}
+	}
+	return csv.StructSlice(rows, fmt.Sprintf("fpl-squad-%s", column))
+}
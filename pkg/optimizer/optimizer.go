@@ -0,0 +1,319 @@
+// Package optimizer provides the best 15-man squad and starting XI under the official
+// budget, position and club rules, exact by branch-and-bound
+package optimizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/aggregate"
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// ErrInfeasible when no squad of the candidates satisfies the rules
+var ErrInfeasible = errors.New("no squad satisfies the rules")
+
+// epsilon below which two objective values are equal
+const epsilon = 1e-9
+
+// Rules of a valid squad, Select, MinPlay and MaxPlay are indexed by element_type
+type Rules struct {
+	Budget    int
+	SquadSize int
+	XISize    int
+	TeamLimit int
+	Select    map[int]int
+	MinPlay   map[int]int
+	MaxPlay   map[int]int
+}
+
+// NewRules from api/bootstrap-static/ game_settings and element_types
+func NewRules(settings fpl.GameSetting, roles []team.PlayerRoles) Rules {
+	r := Rules{
+		Budget:    settings.SquadTotalSpend,
+		SquadSize: settings.SquadSquadsize,
+		XISize:    settings.SquadSquadplay,
+		TeamLimit: settings.SquadTeamLimit,
+		Select:    make(map[int]int, len(roles)),
+		MinPlay:   make(map[int]int, len(roles)),
+		MaxPlay:   make(map[int]int, len(roles)),
+	}
+	for _, role := range roles {
+		r.Select[role.ID] = role.SquadSelect
+		r.MinPlay[role.ID] = role.SquadMinPlay
+		r.MaxPlay[role.ID] = role.SquadMaxPlay
+	}
+	return r
+}
+
+// validate r is internally consistent
+func (r Rules) validate() error {
+	if len(r.Select) == 0 {
+		return fmt.Errorf("rules without element_types: %+v", r)
+	}
+	size := 0
+	for _, n := range r.Select {
+		size += n
+	}
+	if size != r.SquadSize {
+		return fmt.Errorf("squad_select sums to %d, squad_squadsize is %d", size, r.SquadSize)
+	}
+	if r.XISize <= 0 || r.XISize > r.SquadSize || r.TeamLimit <= 0 {
+		return fmt.Errorf("invalid squad_squadplay %d or squad_team_limit %d", r.XISize, r.TeamLimit)
+	}
+	return nil
+}
+
+// Candidate player with the Score to maximise
+type Candidate struct {
+	ID     int
+	TeamID int
+	RoleID int
+	Cost   int
+	Score  float64
+}
+
+// Candidates from players scored by column, any numeric team.Player field such as
+// TotalPoints, Form or PointsPerGame
+func Candidates(players []team.Player, column string) ([]Candidate, error) {
+	candidates := make([]Candidate, 0, len(players))
+	for _, p := range players {
+		score, err := aggregate.Field(p, column)
+		if err != nil {
+			return nil, fmt.Errorf("score column: %v", err)
+		}
+		candidates = append(candidates, candidate(p, score))
+	}
+	return candidates, nil
+}
+
+// CandidatesFunc from players scored by score, e.g. a projection
+func CandidatesFunc(players []team.Player, score func(p team.Player) float64) []Candidate {
+	candidates := make([]Candidate, 0, len(players))
+	for _, p := range players {
+		candidates = append(candidates, candidate(p, score(p)))
+	}
+	return candidates
+}
+
+func candidate(p team.Player, score float64) Candidate {
+	return Candidate{ID: p.ID, TeamID: p.TeamID, RoleID: p.RoleID, Cost: p.NowCost, Score: score}
+}
+
+// Pick of a Squad, Starter when in the XI
+type Pick struct {
+	Candidate
+	Starter bool
+}
+
+// Squad with the XI first by element_type, then the bench with its goalkeeper first.
+// Score is the objective, XIScore the score of the starters only.
+type Squad struct {
+	Picks     []Pick
+	Cost      int
+	Score     float64
+	XIScore   float64
+	Formation string
+}
+
+// Optimizer finds the Squad maximising the XI score plus BenchWeight (0 to 1) times
+// the bench score, 0 to optimise the XI only
+type Optimizer struct {
+	Rules       Rules
+	BenchWeight float64
+}
+
+// Solve exactly over candidates, every valid formation is searched in turn
+// and shares the best squad so far as its bound
+func (o Optimizer) Solve(ctx context.Context, candidates []Candidate) (Squad, error) {
+	if err := o.Rules.validate(); err != nil {
+		return Squad{}, err
+	}
+	s := &search{
+		ctx:       ctx,
+		weight:    math.Max(0, math.Min(1, o.BenchWeight)),
+		teamLimit: o.Rules.TeamLimit,
+		teams:     make(map[int]int),
+	}
+	for roleID := range o.Rules.Select {
+		s.roles = append(s.roles, roleID)
+	}
+	sort.Ints(s.roles)
+
+	full := (o.Rules.SquadSize - 1) / o.Rules.TeamLimit
+	for _, roleID := range s.roles {
+		role := []Candidate{}
+		for _, c := range candidates {
+			if c.RoleID == roleID {
+				role = append(role, c)
+			}
+		}
+		role = prune(role, o.Rules.Select[roleID], full)
+		sort.Slice(role, func(i, j int) bool { return better(role[i], role[j]) })
+		s.byRole = append(s.byRole, role)
+		s.need = append(s.need, o.Rules.Select[roleID])
+	}
+	s.prepare()
+
+	for _, starters := range o.formations(s.roles) {
+		s.starters = starters
+		s.prepareFormation()
+		s.branch(0, 0, 0, o.Rules.Budget, 0)
+		if s.err != nil {
+			return Squad{}, s.err
+		}
+	}
+	if !s.found {
+		return Squad{}, ErrInfeasible
+	}
+	return s.best, nil
+}
+
+// Lineup best XI of squad by score over every valid formation, without a budget
+func (o Optimizer) Lineup(squad []Candidate) Squad {
+	roles := make([]int, 0, len(o.Rules.Select))
+	for roleID := range o.Rules.Select {
+		roles = append(roles, roleID)
+	}
+	sort.Ints(roles)
+	byRole := make([][]Candidate, len(roles))
+	for r, roleID := range roles {
+		for _, c := range squad {
+			if c.RoleID == roleID {
+				byRole[r] = append(byRole[r], c)
+			}
+		}
+		sort.Slice(byRole[r], func(i, j int) bool { return better(byRole[r][i], byRole[r][j]) })
+	}
+
+	bestScore := math.Inf(-1)
+	var best []int
+	for _, starters := range o.formations(roles) {
+		score := 0.0
+		for r, n := range starters {
+			if n > len(byRole[r]) {
+				score = math.Inf(-1)
+				break
+			}
+			for _, c := range byRole[r][:n] {
+				score += c.Score
+			}
+		}
+		if score > bestScore {
+			bestScore, best = score, starters
+		}
+	}
+	picks := make([]Pick, 0, len(squad))
+	for r, role := range byRole {
+		for i, c := range role {
+			picks = append(picks, Pick{Candidate: c, Starter: best != nil && i < best[r]})
+		}
+	}
+	sq := newSquad(picks, roles, best)
+	sq.Score = sq.XIScore
+	return sq
+}
+
+// newSquad of picks, ordered with the XI by role and the bench with its
+// goalkeeper (roles[0]) first, then by score
+func newSquad(picks []Pick, roles, starters []int) Squad {
+	sort.SliceStable(picks, func(i, j int) bool {
+		if picks[i].Starter != picks[j].Starter {
+			return picks[i].Starter
+		}
+		if !picks[i].Starter && (picks[i].RoleID == roles[0]) != (picks[j].RoleID == roles[0]) {
+			return picks[i].RoleID == roles[0]
+		}
+		if picks[i].Starter && picks[i].RoleID != picks[j].RoleID {
+			return picks[i].RoleID < picks[j].RoleID
+		}
+		return better(picks[i].Candidate, picks[j].Candidate)
+	})
+	sq := Squad{Picks: picks}
+	for _, p := range picks {
+		sq.Cost += p.Cost
+		if p.Starter {
+			sq.XIScore += p.Score
+		}
+	}
+	formation := []string{}
+	for r := 1; r < len(starters); r++ {
+		formation = append(formation, strconv.Itoa(starters[r]))
+	}
+	sq.Formation = strings.Join(formation, "-")
+	return sq
+}
+
+// formations of starters per role within MinPlay and MaxPlay, summing to XISize
+func (o Optimizer) formations(roles []int) [][]int {
+	res := [][]int{}
+	var walk func(i, left int, starters []int)
+	walk = func(i, left int, starters []int) {
+		if i == len(roles) {
+			if left == 0 {
+				res = append(res, append([]int{}, starters...))
+			}
+			return
+		}
+		roleID := roles[i]
+		max := o.Rules.MaxPlay[roleID]
+		if max > o.Rules.Select[roleID] {
+			max = o.Rules.Select[roleID]
+		}
+		for n := o.Rules.MinPlay[roleID]; n <= max && n <= left; n++ {
+			walk(i+1, left-n, append(starters, n))
+		}
+	}
+	walk(0, o.Rules.XISize, nil)
+	return res
+}
+
+// better ordering of candidates within a role: higher score, then cheaper, then lower ID
+func better(a, b Candidate) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Cost != b.Cost {
+		return a.Cost < b.Cost
+	}
+	return a.ID < b.ID
+}
+
+// dominates when a scores at least as much as b for at most its cost
+func dominates(a, b Candidate) bool {
+	return a.Score >= b.Score && a.Cost <= b.Cost && better(a, b)
+}
+
+// prune candidates of a role with m squad places that no optimal squad needs.
+// A squad holding b keeps at most m-1 of its dominators and at most full other clubs
+// at the limit, so once b has m dominators in its own club, or its own-club dominators
+// plus other dominating clubs reach m+full, one of them can always replace b
+// without losing score or budget.
+func prune(role []Candidate, m, full int) []Candidate {
+	kept := []Candidate{}
+	for _, b := range role {
+		own := 0
+		otherTeams := make(map[int]bool)
+		for _, a := range role {
+			if !dominates(a, b) {
+				continue
+			}
+			if a.TeamID == b.TeamID {
+				own++
+			} else {
+				otherTeams[a.TeamID] = true
+			}
+		}
+		if own >= m || own+len(otherTeams) >= m+full {
+			continue
+		}
+		kept = append(kept, b)
+	}
+	return kept
+}
@@ -0,0 +1,255 @@
+package optimizer_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/fpltest"
+	"github.com/jadugnap/golang-fpl-101/pkg/optimizer"
+)
+
+// smallRules of a 7-man squad with a 5-man XI, small enough to brute force
+var smallRules = optimizer.Rules{
+	Budget:    330,
+	SquadSize: 7,
+	XISize:    5,
+	TeamLimit: 2,
+	Select:    map[int]int{1: 2, 2: 2, 3: 2, 4: 1},
+	MinPlay:   map[int]int{1: 1, 2: 1, 3: 1, 4: 0},
+	MaxPlay:   map[int]int{1: 1, 2: 2, 3: 2, 4: 1},
+}
+
+func TestSolveBruteForce(t *testing.T) {
+	tests := []struct {
+		name        string
+		seed        int64
+		perRole     int
+		budget      int
+		benchWeight float64
+	}{
+		{name: "xi only", seed: 1, perRole: 5, budget: 330},
+		{name: "half bench", seed: 2, perRole: 5, budget: 330, benchWeight: 0.5},
+		{name: "full bench", seed: 3, perRole: 5, budget: 330, benchWeight: 1},
+		{name: "tight budget", seed: 4, perRole: 6, budget: 310, benchWeight: 0.1},
+		{name: "loose budget", seed: 5, perRole: 6, budget: 1000, benchWeight: 0.1},
+		{name: "infeasible budget", seed: 6, perRole: 4, budget: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := smallRules
+			rules.Budget = tt.budget
+			candidates := randomCandidates(tt.seed, tt.perRole)
+			want, feasible := bruteForce(rules, candidates, tt.benchWeight)
+
+			o := optimizer.Optimizer{Rules: rules, BenchWeight: tt.benchWeight}
+			sq, err := o.Solve(context.Background(), candidates)
+			if !feasible {
+				if !errors.Is(err, optimizer.ErrInfeasible) {
+					t.Fatalf("Solve() error = %v, want %v", err, optimizer.ErrInfeasible)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Solve() error = %v", err)
+			}
+			checkSquad(t, rules, sq)
+			if math.Abs(sq.Score-want) > 1e-9 {
+				t.Errorf("Solve() score = %v, brute force %v", sq.Score, want)
+			}
+		})
+	}
+}
+
+func TestSolveSeason(t *testing.T) {
+	res := fpltest.NewSeason(1, 20, 6, 4).Bootstrap
+	rules := optimizer.NewRules(res.GameSettings, res.PlayerRoles)
+	tests := []struct {
+		column      string
+		benchWeight float64
+	}{
+		{column: "TotalPoints"},
+		{column: "TotalPoints", benchWeight: 0.2},
+		{column: "Form", benchWeight: 0.1},
+		{column: "NowCost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			candidates, err := optimizer.Candidates(res.Players, tt.column)
+			if err != nil {
+				t.Fatal(err)
+			}
+			o := optimizer.Optimizer{Rules: rules, BenchWeight: tt.benchWeight}
+			sq, err := o.Solve(context.Background(), candidates)
+			if err != nil {
+				t.Fatalf("Solve() error = %v", err)
+			}
+			checkSquad(t, rules, sq)
+		})
+	}
+}
+
+func TestSolveInvalidRules(t *testing.T) {
+	rules := smallRules
+	rules.SquadSize = 8
+	if _, err := (optimizer.Optimizer{Rules: rules}).Solve(context.Background(), randomCandidates(1, 3)); err == nil {
+		t.Errorf("Solve() with select not summing to the squad size, want an error")
+	}
+}
+
+// checkSquad against the budget, squad shape, club limit and formation of rules
+func checkSquad(t *testing.T, rules optimizer.Rules, sq optimizer.Squad) {
+	t.Helper()
+	if len(sq.Picks) != rules.SquadSize {
+		t.Fatalf("squad of %d, want %d", len(sq.Picks), rules.SquadSize)
+	}
+	cost, xi, xiScore := 0, 0, 0.0
+	roles, starters, clubs := map[int]int{}, map[int]int{}, map[int]int{}
+	seen := map[int]bool{}
+	for _, p := range sq.Picks {
+		if seen[p.ID] {
+			t.Errorf("player %d picked twice", p.ID)
+		}
+		seen[p.ID] = true
+		cost += p.Cost
+		roles[p.RoleID]++
+		clubs[p.TeamID]++
+		if p.Starter {
+			xi++
+			starters[p.RoleID]++
+			xiScore += p.Score
+		}
+	}
+	if cost != sq.Cost || cost > rules.Budget {
+		t.Errorf("squad cost %d (reported %d) over budget %d", cost, sq.Cost, rules.Budget)
+	}
+	if math.Abs(xiScore-sq.XIScore) > 1e-9 {
+		t.Errorf("XIScore = %v, starters sum to %v", sq.XIScore, xiScore)
+	}
+	for teamID, n := range clubs {
+		if n > rules.TeamLimit {
+			t.Errorf("%d players of team %d, limit %d", n, teamID, rules.TeamLimit)
+		}
+	}
+	if xi != rules.XISize {
+		t.Errorf("XI of %d, want %d", xi, rules.XISize)
+	}
+	for roleID, n := range rules.Select {
+		if roles[roleID] != n {
+			t.Errorf("%d players of role %d, want %d", roles[roleID], roleID, n)
+		}
+		if starters[roleID] < rules.MinPlay[roleID] || starters[roleID] > rules.MaxPlay[roleID] {
+			t.Errorf("%d starters of role %d, want %d to %d", starters[roleID], roleID, rules.MinPlay[roleID], rules.MaxPlay[roleID])
+		}
+	}
+	formation := []string{}
+	for roleID := 2; roleID <= len(rules.Select); roleID++ {
+		formation = append(formation, strconv.Itoa(starters[roleID]))
+	}
+	if got := strings.Join(formation, "-"); sq.Formation != got {
+		t.Errorf("Formation = %q, starters are %q", sq.Formation, got)
+	}
+	// XI first, then the bench goalkeeper
+	for i, p := range sq.Picks {
+		if p.Starter != (i < rules.XISize) {
+			t.Errorf("pick %d starter %v", i, p.Starter)
+		}
+	}
+	if bench := sq.Picks[rules.XISize]; bench.RoleID != 1 {
+		t.Errorf("first on the bench of role %d, want the goalkeeper", bench.RoleID)
+	}
+}
+
+// randomCandidates of perRole players in every role of smallRules over 4 clubs
+func randomCandidates(seed int64, perRole int) []optimizer.Candidate {
+	r := rand.New(rand.NewSource(seed))
+	candidates := []optimizer.Candidate{}
+	for roleID := 1; roleID <= 4; roleID++ {
+		for i := 0; i < perRole; i++ {
+			candidates = append(candidates, optimizer.Candidate{
+				ID:     len(candidates) + 1,
+				TeamID: 1 + r.Intn(4),
+				RoleID: roleID,
+				Cost:   35 + 5*r.Intn(9),
+				Score:  float64(r.Intn(100)) / 10,
+			})
+		}
+	}
+	return candidates
+}
+
+// bruteForce best objective over every squad of candidates, false when none is valid
+func bruteForce(rules optimizer.Rules, candidates []optimizer.Candidate, benchWeight float64) (float64, bool) {
+	byRole := map[int][]optimizer.Candidate{}
+	for _, c := range candidates {
+		byRole[c.RoleID] = append(byRole[c.RoleID], c)
+	}
+	best, found := math.Inf(-1), false
+	var walk func(roleID int, squad []optimizer.Candidate)
+	walk = func(roleID int, squad []optimizer.Candidate) {
+		if roleID > len(rules.Select) {
+			if score, ok := objective(rules, squad, benchWeight); ok && score > best {
+				best, found = score, true
+			}
+			return
+		}
+		for _, chosen := range combinations(byRole[roleID], rules.Select[roleID]) {
+			walk(roleID+1, append(append([]optimizer.Candidate{}, squad...), chosen...))
+		}
+	}
+	walk(1, nil)
+	return best, found
+}
+
+// objective of squad, its best valid XI plus benchWeight times the rest, false when invalid
+func objective(rules optimizer.Rules, squad []optimizer.Candidate, benchWeight float64) (float64, bool) {
+	cost, total := 0, 0.0
+	clubs := map[int]int{}
+	for _, c := range squad {
+		cost += c.Cost
+		total += c.Score
+		clubs[c.TeamID]++
+		if clubs[c.TeamID] > rules.TeamLimit {
+			return 0, false
+		}
+	}
+	if cost > rules.Budget {
+		return 0, false
+	}
+	best := math.Inf(-1)
+	for _, xi := range combinations(squad, rules.XISize) {
+		starters, score := map[int]int{}, 0.0
+		for _, c := range xi {
+			starters[c.RoleID]++
+			score += c.Score
+		}
+		valid := true
+		for roleID := range rules.Select {
+			if starters[roleID] < rules.MinPlay[roleID] || starters[roleID] > rules.MaxPlay[roleID] {
+				valid = false
+			}
+		}
+		if valid && score > best {
+			best = score
+		}
+	}
+	return best + benchWeight*(total-best), !math.IsInf(best, -1)
+}
+
+// combinations of k out of candidates
+func combinations(candidates []optimizer.Candidate, k int) [][]optimizer.Candidate {
+	if k == 0 {
+		return [][]optimizer.Candidate{nil}
+	}
+	res := [][]optimizer.Candidate{}
+	for i := 0; i+k <= len(candidates); i++ {
+		for _, rest := range combinations(candidates[i+1:], k-1) {
+			res = append(res, append([]optimizer.Candidate{candidates[i]}, rest...))
+		}
+	}
+	return res
+}
@@ -0,0 +1,210 @@
+package optimizer
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// search state of a branch-and-bound over roles in order, then candidates by score.
+// Within a role the first picks in score order are the starters of the formation.
+type search struct {
+	ctx       context.Context
+	err       error
+	nodes     int
+	weight    float64
+	teamLimit int
+
+	roles    []int
+	byRole   [][]Candidate
+	need     []int
+	starters []int
+
+	// lambdas of the Lagrangian budget bound, with candidate indices per lambda
+	// and role ranked by Score-lambda*Cost as starter and as bench
+	lambdas   []float64
+	starterBy [][][]int
+	benchBy   [][][]int
+	costBy    [][]int
+	// after per lambda and role: bound of every later role, minCostAfter per role
+	after        [][]float64
+	minCostAfter []int
+
+	teams     map[int]int
+	chosen    []Pick
+	found     bool
+	bestScore float64
+	best      Squad
+}
+
+// prepare lambdas and rankings shared by every formation
+func (s *search) prepare() {
+	ratios := []float64{}
+	for _, role := range s.byRole {
+		for _, c := range role {
+			if c.Score > 0 && c.Cost > 0 {
+				ratios = append(ratios, c.Score/float64(c.Cost))
+			}
+		}
+	}
+	sort.Float64s(ratios)
+	s.lambdas = []float64{0}
+	for _, q := range []float64{0.5, 0.7, 0.85, 0.95} {
+		if len(ratios) > 0 {
+			s.lambdas = append(s.lambdas, ratios[int(q*float64(len(ratios)-1))])
+		}
+	}
+
+	for _, lambda := range s.lambdas {
+		starterBy := make([][]int, len(s.byRole))
+		benchBy := make([][]int, len(s.byRole))
+		for r, role := range s.byRole {
+			starterBy[r] = rank(role, func(c Candidate) float64 { return c.Score - lambda*float64(c.Cost) })
+			benchBy[r] = rank(role, func(c Candidate) float64 { return s.weight*c.Score - lambda*float64(c.Cost) })
+		}
+		s.starterBy = append(s.starterBy, starterBy)
+		s.benchBy = append(s.benchBy, benchBy)
+	}
+	s.costBy = make([][]int, len(s.byRole))
+	for r, role := range s.byRole {
+		s.costBy[r] = rank(role, func(c Candidate) float64 { return -float64(c.Cost) })
+	}
+	s.minCostAfter = make([]int, len(s.byRole)+1)
+	for r := len(s.byRole) - 1; r >= 0; r-- {
+		cost, _ := s.cheapest(r, 0, s.need[r])
+		s.minCostAfter[r] = s.minCostAfter[r+1] + cost
+	}
+}
+
+// prepareFormation bounds of later roles for the current starters
+func (s *search) prepareFormation() {
+	s.after = make([][]float64, len(s.lambdas))
+	for l, lambda := range s.lambdas {
+		s.after[l] = make([]float64, len(s.byRole)+1)
+		for r := len(s.byRole) - 1; r >= 0; r-- {
+			bound := s.roleBound(l, lambda, r, 0, 0)
+			s.after[l][r] = s.after[l][r+1] + bound
+		}
+	}
+}
+
+// rank indices of role by value, highest first
+func rank(role []Candidate, value func(c Candidate) float64) []int {
+	idx := make([]int, len(role))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return value(role[idx[i]]) > value(role[idx[j]]) })
+	return idx
+}
+
+// top sum of value over the first n ranked indices from i on, false with fewer left
+func top(ranked []int, i, n int, value func(idx int) float64) (float64, bool) {
+	sum := 0.0
+	for _, idx := range ranked {
+		if n == 0 {
+			break
+		}
+		if idx < i {
+			continue
+		}
+		sum += value(idx)
+		n--
+	}
+	return sum, n == 0
+}
+
+// cheapest cost of n candidates of role r from index i on
+func (s *search) cheapest(r, i, n int) (int, bool) {
+	role := s.byRole[r]
+	sum, ok := top(s.costBy[r], i, n, func(idx int) float64 { return float64(role[idx].Cost) })
+	return int(sum), ok
+}
+
+// roleBound of the picks left in role r from index i with k picked, at lambda.
+// Starters and bench are bounded separately, so a candidate may count in both.
+func (s *search) roleBound(l int, lambda float64, r, i, k int) float64 {
+	role := s.byRole[r]
+	startersLeft := s.starters[r] - k
+	if startersLeft < 0 {
+		startersLeft = 0
+	}
+	benchLeft := s.need[r] - k - startersLeft
+	starter, ok1 := top(s.starterBy[l][r], i, startersLeft, func(idx int) float64 {
+		return role[idx].Score - lambda*float64(role[idx].Cost)
+	})
+	bench, ok2 := top(s.benchBy[l][r], i, benchLeft, func(idx int) float64 {
+		return s.weight*role[idx].Score - lambda*float64(role[idx].Cost)
+	})
+	if !ok1 || !ok2 {
+		return math.Inf(-1)
+	}
+	return starter + bench
+}
+
+// bound of the best completion from role r, index i with k picked and budget left,
+// the tightest Lagrangian relaxation of the budget over every lambda
+func (s *search) bound(r, i, k, budget int) float64 {
+	best := math.Inf(1)
+	for l, lambda := range s.lambdas {
+		bound := lambda*float64(budget) + s.roleBound(l, lambda, r, i, k) + s.after[l][r+1]
+		best = math.Min(best, bound)
+	}
+	return best
+}
+
+// branch on including then excluding candidate i of role r
+func (s *search) branch(r, i, k, budget int, value float64) {
+	if s.err != nil {
+		return
+	}
+	s.nodes++
+	if s.nodes%4096 == 0 {
+		if err := s.ctx.Err(); err != nil {
+			s.err = err
+			return
+		}
+	}
+	if k == s.need[r] {
+		if r+1 == len(s.roles) {
+			s.record(value)
+			return
+		}
+		s.branch(r+1, 0, 0, budget, value)
+		return
+	}
+	role := s.byRole[r]
+	cost, ok := s.cheapest(r, i, s.need[r]-k)
+	if !ok || cost+s.minCostAfter[r+1] > budget {
+		return
+	}
+	if s.found && value+s.bound(r, i, k, budget) <= s.bestScore+epsilon {
+		return
+	}
+
+	c := role[i]
+	if c.Cost <= budget && s.teams[c.TeamID] < s.teamLimit {
+		starter := k < s.starters[r]
+		weight := s.weight
+		if starter {
+			weight = 1
+		}
+		s.teams[c.TeamID]++
+		s.chosen = append(s.chosen, Pick{Candidate: c, Starter: starter})
+		s.branch(r, i+1, k+1, budget-c.Cost, value+weight*c.Score)
+		s.chosen = s.chosen[:len(s.chosen)-1]
+		s.teams[c.TeamID]--
+	}
+	s.branch(r, i+1, k, budget, value)
+}
+
+// record the chosen squad when better than the best so far
+func (s *search) record(value float64) {
+	if s.found && value <= s.bestScore+epsilon {
+		return
+	}
+	s.found = true
+	s.bestScore = value
+	s.best = newSquad(append([]Pick{}, s.chosen...), s.roles, s.starters)
+	s.best.Score = value
+}
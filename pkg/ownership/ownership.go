@@ -0,0 +1,251 @@
+// Package ownership provides effective ownership of players within a mini-league
+package ownership
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/league"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// Ownership of a player among league members in a gameweek, percentages of members.
+// EffectiveOwnership sums every member's multiplier, e.g. 150 when all start him and half captain him.
+type Ownership struct {
+	Gameweek           int
+	PlayerID           int
+	Members            int
+	Owners             int
+	Starters           int
+	Captains           int
+	TripleCaptains     int
+	Ownership          float64
+	Captaincy          float64
+	EffectiveOwnership float64
+}
+
+// Swing of a player for one manager against the league if he returns Returns points
+type Swing struct {
+	Gameweek           int
+	PlayerID           int
+	MyMultiplier       int
+	EffectiveOwnership float64
+	GainPerPoint       float64
+	Returns            int
+	Gain               float64
+}
+
+// Row of an Ownership joined to team.Player for csv.StructSlice
+type Row struct {
+	Gameweek           int
+	PlayerID           int
+	WebName            string
+	TeamName           string
+	RoleName           string
+	Members            int
+	Owners             int
+	Starters           int
+	Captains           int
+	TripleCaptains     int
+	Ownership          float64
+	Captaincy          float64
+	EffectiveOwnership float64
+}
+
+// SwingRow of a Swing joined to team.Player for csv.StructSlice
+type SwingRow struct {
+	Gameweek           int
+	PlayerID           int
+	WebName            string
+	TeamName           string
+	MyMultiplier       int
+	EffectiveOwnership float64
+	Returns            int
+	Gain               float64
+}
+
+// Calculate ownership of every picked player among entry2Picks of gameweek gw, highest EO first
+func Calculate(gw int, entry2Picks map[int]entry.PicksResponse) []Ownership {
+	id2Ownership := make(map[int]*Ownership)
+	members := len(entry2Picks)
+	for _, picks := range entry2Picks {
+		for _, pick := range picks.Picks {
+			o, ok := id2Ownership[pick.Element]
+			if !ok {
+				o = &Ownership{Gameweek: gw, PlayerID: pick.Element, Members: members}
+				id2Ownership[pick.Element] = o
+			}
+			multiplier := entry.Multiplier(pick, picks.ActiveChip)
+			o.Owners++
+			if multiplier > 0 {
+				o.Starters++
+			}
+			if pick.IsCaptain {
+				o.Captains++
+				if picks.ActiveChip == entry.TripleCaptain {
+					o.TripleCaptains++
+				}
+			}
+			o.EffectiveOwnership += float64(multiplier)
+		}
+	}
+
+	ownerships := make([]Ownership, 0, len(id2Ownership))
+	for _, o := range id2Ownership {
+		o.Ownership = percent(o.Owners, members)
+		o.Captaincy = percent(o.Captains, members)
+		o.EffectiveOwnership = o.EffectiveOwnership / float64(members) * 100
+		ownerships = append(ownerships, *o)
+	}
+	sort.Slice(ownerships, func(i, j int) bool {
+		if ownerships[i].EffectiveOwnership != ownerships[j].EffectiveOwnership {
+			return ownerships[i].EffectiveOwnership > ownerships[j].EffectiveOwnership
+		}
+		return ownerships[i].PlayerID < ownerships[j].PlayerID
+	})
+	return ownerships
+}
+
+// ByPlayer EffectiveOwnership of ownerships
+func ByPlayer(ownerships []Ownership) map[int]float64 {
+	id2EO := make(map[int]float64, len(ownerships))
+	for _, o := range ownerships {
+		id2EO[o.PlayerID] = o.EffectiveOwnership
+	}
+	return id2EO
+}
+
+// Swings for my picks against ownerships of my rivals, without my own entry, if every
+// player returns points, biggest swing first. Gain is what I win (or lose when negative)
+// against the rivals' average.
+func Swings(my entry.PicksResponse, ownerships []Ownership, returns int) []Swing {
+	myMultiplier := make(map[int]int, len(my.Picks))
+	for _, pick := range my.Picks {
+		myMultiplier[pick.Element] = entry.Multiplier(pick, my.ActiveChip)
+	}
+	// my picks no rival owns
+	owned := make(map[int]bool, len(ownerships))
+	for _, o := range ownerships {
+		owned[o.PlayerID] = true
+	}
+	for _, pick := range my.Picks {
+		if !owned[pick.Element] {
+			ownerships = append(ownerships, Ownership{PlayerID: pick.Element})
+		}
+	}
+
+	swings := make([]Swing, 0, len(ownerships))
+	for _, o := range ownerships {
+		s := Swing{
+			Gameweek:           my.EntryHistory.Event,
+			PlayerID:           o.PlayerID,
+			MyMultiplier:       myMultiplier[o.PlayerID],
+			EffectiveOwnership: o.EffectiveOwnership,
+			Returns:            returns,
+		}
+		s.GainPerPoint = float64(s.MyMultiplier) - o.EffectiveOwnership/100
+		s.Gain = s.GainPerPoint * float64(returns)
+		swings = append(swings, s)
+	}
+	sort.SliceStable(swings, func(i, j int) bool {
+		return math.Abs(swings[i].Gain) > math.Abs(swings[j].Gain)
+	})
+	return swings
+}
+
+// LeagueToCsv ownership of every fetched gameweek of l, and swings for entry me unless 0
+func LeagueToCsv(l *league.League, players []team.Player, me, returns int) error {
+	id2Player := make(map[int]team.Player, len(players))
+	for _, p := range players {
+		id2Player[p.ID] = p
+	}
+
+	gw2Entry2Picks := make(map[int]map[int]entry.PicksResponse)
+	for entryID, gw2Picks := range l.Entry2Gw2Picks {
+		for gw, picks := range gw2Picks {
+			if _, ok := gw2Entry2Picks[gw]; !ok {
+				gw2Entry2Picks[gw] = make(map[int]entry.PicksResponse)
+			}
+			gw2Entry2Picks[gw][entryID] = picks
+		}
+	}
+
+	for gw, entry2Picks := range gw2Entry2Picks {
+		ownerships := Calculate(gw, entry2Picks)
+		if err := csv.StructSlice(Rows(ownerships, id2Player), fmt.Sprintf("fpl-leagues/%d-gw%d-ownership", l.ID, gw)); err != nil {
+			return err
+		}
+		if my, ok := entry2Picks[me]; ok {
+			swingRows := SwingRows(Swings(my, Calculate(gw, Rivals(entry2Picks, me)), returns), id2Player)
+			if err := csv.StructSlice(swingRows, fmt.Sprintf("fpl-leagues/%d-gw%d-swing-%d", l.ID, gw, me)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Rivals of entry me among entry2Picks, whose ownership my own picks must not inflate
+func Rivals(entry2Picks map[int]entry.PicksResponse, me int) map[int]entry.PicksResponse {
+	rival2Picks := make(map[int]entry.PicksResponse, len(entry2Picks))
+	for entryID, picks := range entry2Picks {
+		if entryID != me {
+			rival2Picks[entryID] = picks
+		}
+	}
+	return rival2Picks
+}
+
+// Rows of ownerships joined to id2Player
+func Rows(ownerships []Ownership, id2Player map[int]team.Player) []Row {
+	rows := make([]Row, len(ownerships))
+	for i, o := range ownerships {
+		p := id2Player[o.PlayerID]
+		rows[i] = Row{
+			Gameweek:           o.Gameweek,
+			PlayerID:           o.PlayerID,
+			WebName:            p.WebName,
+			TeamName:           p.TeamName,
+			RoleName:           p.RoleName,
+			Members:            o.Members,
+			Owners:             o.Owners,
+			Starters:           o.Starters,
+			Captains:           o.Captains,
+			TripleCaptains:     o.TripleCaptains,
+			Ownership:          o.Ownership,
+			Captaincy:          o.Captaincy,
+			EffectiveOwnership: o.EffectiveOwnership,
+		}
+	}
+	return rows
+}
+
+// SwingRows of swings joined to id2Player
+func SwingRows(swings []Swing, id2Player map[int]team.Player) []SwingRow {
+	rows := make([]SwingRow, len(swings))
+	for i, s := range swings {
+		p := id2Player[s.PlayerID]
+		rows[i] = SwingRow{
+			Gameweek:           s.Gameweek,
+			PlayerID:           s.PlayerID,
+			WebName:            p.WebName,
+			TeamName:           p.TeamName,
+			MyMultiplier:       s.MyMultiplier,
+			EffectiveOwnership: s.EffectiveOwnership,
+			Returns:            s.Returns,
+			Gain:               s.Gain,
+		}
+	}
+	return rows
+}
+
+// percent of n over total
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
@@ -0,0 +1,100 @@
+package ownership_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/ownership"
+)
+
+// picks of element at position, captained if captain, with activeChip
+func picks(element, position int, captain bool, activeChip string) entry.PicksResponse {
+	return entry.PicksResponse{
+		ActiveChip:   activeChip,
+		EntryHistory: entry.GameweekHistory{Event: 5},
+		Picks:        []entry.Pick{{Element: element, Position: position, IsCaptain: captain}},
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry2Picks map[int]entry.PicksResponse
+		want        ownership.Ownership
+	}{
+		{
+			name:        "captain",
+			entry2Picks: map[int]entry.PicksResponse{1: picks(10, 1, true, ""), 2: picks(10, 1, true, "")},
+			want:        ownership.Ownership{Members: 2, Owners: 2, Starters: 2, Captains: 2, Ownership: 100, Captaincy: 100, EffectiveOwnership: 200},
+		},
+		{
+			name:        "triple captain",
+			entry2Picks: map[int]entry.PicksResponse{1: picks(10, 1, true, entry.TripleCaptain), 2: picks(10, 1, true, entry.TripleCaptain)},
+			want:        ownership.Ownership{Members: 2, Owners: 2, Starters: 2, Captains: 2, TripleCaptains: 2, Ownership: 100, Captaincy: 100, EffectiveOwnership: 300},
+		},
+		{
+			name:        "bench boost bench",
+			entry2Picks: map[int]entry.PicksResponse{1: picks(10, 13, false, entry.BenchBoost), 2: picks(10, 3, false, "")},
+			want:        ownership.Ownership{Members: 2, Owners: 2, Starters: 2, Ownership: 100, EffectiveOwnership: 100},
+		},
+		{
+			name:        "bench without bench boost",
+			entry2Picks: map[int]entry.PicksResponse{1: picks(10, 13, false, ""), 2: picks(10, 3, false, "")},
+			want:        ownership.Ownership{Members: 2, Owners: 2, Starters: 1, Ownership: 100, EffectiveOwnership: 50},
+		},
+		{
+			name:        "one of four members",
+			entry2Picks: map[int]entry.PicksResponse{1: picks(10, 1, true, ""), 2: picks(11, 1, true, ""), 3: picks(11, 1, true, ""), 4: picks(11, 1, true, "")},
+			want:        ownership.Ownership{Members: 4, Owners: 1, Starters: 1, Captains: 1, Ownership: 25, Captaincy: 25, EffectiveOwnership: 50},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Gameweek, tt.want.PlayerID = 5, 10
+			for _, o := range ownership.Calculate(5, tt.entry2Picks) {
+				if o.PlayerID != 10 {
+					continue
+				}
+				if !reflect.DeepEqual(o, tt.want) {
+					t.Errorf("Calculate() = %+v, want %+v", o, tt.want)
+				}
+				return
+			}
+			t.Errorf("Calculate() without player 10")
+		})
+	}
+}
+
+func TestSwings(t *testing.T) {
+	const me = 1
+	entry2Picks := map[int]entry.PicksResponse{
+		me: {EntryHistory: entry.GameweekHistory{Event: 5}, Picks: []entry.Pick{
+			{Element: 10, Position: 1, IsCaptain: true},
+			{Element: 11, Position: 2},
+			{Element: 12, Position: 12},
+		}},
+		2: {Picks: []entry.Pick{{Element: 11, Position: 1}, {Element: 13, Position: 2}}},
+		3: {Picks: []entry.Pick{{Element: 11, Position: 1, IsCaptain: true}}},
+	}
+	rivals := ownership.Rivals(entry2Picks, me)
+	if _, ok := rivals[me]; ok || len(rivals) != 2 {
+		t.Fatalf("Rivals() = %v, want entries 2 and 3", rivals)
+	}
+
+	got := ownership.Swings(entry2Picks[me], ownership.Calculate(5, rivals), 6)
+	// biggest swing first
+	want := []ownership.Swing{
+		// captained and no rival owns him
+		{Gameweek: 5, PlayerID: 10, MyMultiplier: 2, GainPerPoint: 2, Returns: 6, Gain: 12},
+		// I start him, rivals own him at 150%
+		{Gameweek: 5, PlayerID: 11, MyMultiplier: 1, EffectiveOwnership: 150, GainPerPoint: -0.5, Returns: 6, Gain: -3},
+		// only a rival starts him
+		{Gameweek: 5, PlayerID: 13, EffectiveOwnership: 50, GainPerPoint: -0.5, Returns: 6, Gain: -3},
+		// on my bench and no rival owns him
+		{Gameweek: 5, PlayerID: 12, Returns: 6},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Swings() =\n%+v\nwant\n%+v", got, want)
+	}
+}
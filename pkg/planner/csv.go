@@ -0,0 +1,58 @@
+package planner
+
+import (
+	"fmt"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/aggregate"
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// Row of a ranked Plan for csv.StructSlice
+type Row struct {
+	Entry         int
+	Rank          int
+	Transfers     string
+	Hits          int
+	Points        float64
+	Gain          float64
+	Bank          int
+	FreeTransfers int
+}
+
+// ColumnProjection of a numeric team.Player column per fixture, e.g. PointsPerGame or Form,
+// times the fixtures of the player's team in the gameweek: 0 on a blank, double on a double
+func ColumnProjection(players []team.Player, column string, x *fixtures.Fixtures) (Projection, error) {
+	id2Value := make(map[int]float64, len(players))
+	id2Team := make(map[int]int, len(players))
+	for _, p := range players {
+		value, err := aggregate.Field(p, column)
+		if err != nil {
+			return nil, fmt.Errorf("projection column: %v", err)
+		}
+		id2Value[p.ID], id2Team[p.ID] = value, p.TeamID
+	}
+	return func(id, gw int) float64 {
+		return id2Value[id] * float64(x.FixtureCount(id2Team[id], gw))
+	}, nil
+}
+
+// EntryToCsv ranked plans of entry over gameweeks
+func EntryToCsv(entryID int, plans []Plan, gameweeks []int, r *registry.Registry) error {
+	rows := make([]Row, len(plans))
+	for i, plan := range plans {
+		rows[i] = Row{
+			Entry:         entryID,
+			Rank:          i + 1,
+			Transfers:     Summary(plan, gameweeks, r.PlayerName),
+			Hits:          plan.Hits,
+			Points:        plan.Points,
+			Gain:          plan.Gain,
+			Bank:          plan.Bank,
+			FreeTransfers: plan.FreeTransfers,
+		}
+	}
+	return csv.StructSlice(rows, fmt.Sprintf("fpl-entries/%d-plans", entryID))
+}
@@ -0,0 +1,250 @@
+// Package planner provides ranked multi-gameweek transfer plans maximising projected points,
+// with free transfers rolling over, hits and the sell-on fee
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/optimizer"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// defaults of a zero Planner
+const (
+	DefaultHitCost          = 4
+	DefaultMaxFreeTransfers = 5
+	DefaultMaxTransfers     = 2
+	DefaultPool             = 15
+	DefaultBeamWidth        = 50
+)
+
+// Projection of player id's points in gameweek gw
+type Projection func(id, gw int) float64
+
+// State of a squad before a gameweek's deadline.
+// PurchasePrice of a player defaults to its NowCost when unknown.
+type State struct {
+	Squad         []int
+	PurchasePrice map[int]int
+	Bank          int
+	FreeTransfers int
+}
+
+// Transfer of Out for In before the deadline of Gameweek
+type Transfer struct {
+	Gameweek  int
+	Out       int
+	In        int
+	SellPrice int
+	BuyPrice  int
+}
+
+// Plan of transfers over the gameweeks, Points net of Hits and Gain over rolling every transfer
+type Plan struct {
+	Transfers     []Transfer
+	Hits          int
+	Points        float64
+	Gain          float64
+	Gw2Points     map[int]float64
+	Bank          int
+	FreeTransfers int
+}
+
+// Planner searches transfer sequences by beam search over gameweeks: every kept state
+// tries no transfer, each single transfer and, with MaxTransfers of 2, pairs of its best
+// singles, buying among the Pool best projected players per position
+type Planner struct {
+	Rules            optimizer.Rules
+	SellOnFee        float64
+	HitCost          int
+	MaxFreeTransfers int
+	MaxTransfers     int
+	Pool             int
+	BeamWidth        int
+	Players          []team.Player
+	Projection       Projection
+}
+
+// FromEntry state after gameweek gw of e, the squad and bank before a free hit when
+// played in gw. PurchasePrice comes from e's Transfers, fetched with FetchTransfers, and
+// for the initial squad from the player's value in e's first gameweek in ID2History.
+func FromEntry(e *entry.Entry, gw, maxFree int) (State, error) {
+	picks, ok := e.Gw2Picks[gw]
+	if ok && picks.ActiveChip == entry.FreeHit {
+		picks, ok = e.Gw2Picks[gw-1]
+	}
+	if !ok {
+		return State{}, fmt.Errorf("no picks of entry %d in gameweek %d", e.ID, gw)
+	}
+	s := State{
+		Bank:          picks.EntryHistory.Bank,
+		FreeTransfers: e.FreeTransfers(maxFree),
+	}
+	for _, pick := range picks.Picks {
+		s.Squad = append(s.Squad, pick.Element)
+	}
+	s.PurchasePrice = purchasePrices(e, gw, s.Squad)
+	return s, nil
+}
+
+// purchasePrices of squad after gameweek gw of e: the cost of each player's latest
+// transfer in, free hit transfers being undone, or his value when e started
+func purchasePrices(e *entry.Entry, gw int, squad []int) map[int]int {
+	freeHits := make(map[int]bool)
+	for _, chip := range e.History.Chips {
+		if chip.Name == entry.FreeHit {
+			freeHits[chip.Event] = true
+		}
+	}
+	transfers := append([]entry.Transfer{}, e.Transfers...)
+	sort.SliceStable(transfers, func(i, j int) bool {
+		if transfers[i].Event != transfers[j].Event {
+			return transfers[i].Event < transfers[j].Event
+		}
+		return transfers[i].Time.Before(transfers[j].Time)
+	})
+	boughtAt := make(map[int]int)
+	for _, t := range transfers {
+		if t.Event <= gw && !freeHits[t.Event] {
+			boughtAt[t.ElementIn] = t.ElementInCost
+		}
+	}
+
+	started := e.Res.StartedEvent
+	if started == 0 && len(e.History.Current) > 0 {
+		started = e.History.Current[0].Event
+	}
+	prices := make(map[int]int, len(squad))
+	for _, id := range squad {
+		if price, ok := boughtAt[id]; ok {
+			prices[id] = price
+			continue
+		}
+		for _, h := range e.ID2History[id] {
+			if h.Round == started {
+				prices[id] = h.Value
+				break
+			}
+		}
+	}
+	return prices
+}
+
+// SellingPrice of a player bought at purchase now costing now: the sell-on fee
+// is kept on a rise, rounded down to 0.1m, and a fall is passed on in full
+func SellingPrice(purchase, now int, sellOnFee float64) int {
+	if now <= purchase {
+		return now
+	}
+	return purchase + int(math.Floor(float64(now-purchase)*(1-sellOnFee)))
+}
+
+// SellingPrice of player id of st now costing now
+func (st State) SellingPrice(id, now int, sellOnFee float64) int {
+	purchase, ok := st.PurchasePrice[id]
+	if !ok {
+		purchase = now
+	}
+	return SellingPrice(purchase, now, sellOnFee)
+}
+
+// Funds of st to rebuild its squad with: the bank plus every player at his selling price
+func (st State) Funds(players []team.Player, sellOnFee float64) int {
+	id2Cost := make(map[int]int, len(players))
+	for _, p := range players {
+		id2Cost[p.ID] = p.NowCost
+	}
+	funds := st.Bank
+	for _, id := range st.Squad {
+		funds += st.SellingPrice(id, id2Cost[id], sellOnFee)
+	}
+	return funds
+}
+
+// node of the beam: a state with the plan leading to it
+type node struct {
+	state State
+	plan  Plan
+}
+
+// Plans over gameweeks from start, the best n first
+func (p Planner) Plans(start State, gameweeks []int, n int) ([]Plan, error) {
+	if p.Projection == nil {
+		return nil, errors.New("planner needs a Projection")
+	}
+	if len(start.Squad) != p.Rules.SquadSize {
+		return nil, fmt.Errorf("squad of %d players, want %d", len(start.Squad), p.Rules.SquadSize)
+	}
+	id2Player := make(map[int]team.Player, len(p.Players))
+	for _, player := range p.Players {
+		id2Player[player.ID] = player
+	}
+	for _, id := range start.Squad {
+		if _, ok := id2Player[id]; !ok {
+			return nil, fmt.Errorf("player %d of the squad is unknown", id)
+		}
+	}
+	s := &search{Planner: p.withDefaults(), id2Player: id2Player, gameweeks: gameweeks}
+	s.fillPool()
+
+	baseline := s.roll(start)
+	beam := []node{{state: start, plan: Plan{Gw2Points: make(map[int]float64)}}}
+	for i := range gameweeks {
+		beam = s.step(beam, i)
+	}
+	plans := make([]Plan, 0, len(beam))
+	for _, nd := range beam {
+		nd.plan.Gain = nd.plan.Points - baseline
+		nd.plan.Bank = nd.state.Bank
+		nd.plan.FreeTransfers = nd.state.FreeTransfers
+		plans = append(plans, nd.plan)
+	}
+	sort.SliceStable(plans, func(i, j int) bool { return plans[i].Points > plans[j].Points })
+	if n > 0 && len(plans) > n {
+		plans = plans[:n]
+	}
+	return plans, nil
+}
+
+// withDefaults for every zero setting
+func (p Planner) withDefaults() Planner {
+	if p.HitCost == 0 {
+		p.HitCost = DefaultHitCost
+	}
+	if p.MaxFreeTransfers == 0 {
+		p.MaxFreeTransfers = DefaultMaxFreeTransfers
+	}
+	if p.MaxTransfers == 0 {
+		p.MaxTransfers = DefaultMaxTransfers
+	}
+	if p.Pool == 0 {
+		p.Pool = DefaultPool
+	}
+	if p.BeamWidth == 0 {
+		p.BeamWidth = DefaultBeamWidth
+	}
+	return p
+}
+
+// Summary of plan's transfers by gameweek, e.g. "GW5: A>B C>D | GW6: roll"
+func Summary(plan Plan, gameweeks []int, name func(id int) string) string {
+	gw2Transfers := make(map[int][]string)
+	for _, t := range plan.Transfers {
+		gw2Transfers[t.Gameweek] = append(gw2Transfers[t.Gameweek], name(t.Out)+">"+name(t.In))
+	}
+	parts := []string{}
+	for _, gw := range gameweeks {
+		transfers := "roll"
+		if len(gw2Transfers[gw]) > 0 {
+			transfers = strings.Join(gw2Transfers[gw], " ")
+		}
+		parts = append(parts, "GW"+strconv.Itoa(gw)+": "+transfers)
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,140 @@
+package planner_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/entry"
+	"github.com/jadugnap/golang-fpl-101/pkg/planner"
+)
+
+// testEntry of squad 1, 2, 3 bought in gameweek 1 at 41, 42 and 43, then each
+// gameweek's picks and bank, with transfers and chips
+func testEntry(gw2Squad map[int][]int, gw2Bank map[int]int, transfers []entry.Transfer, chips []entry.Chip) *entry.Entry {
+	e := &entry.Entry{
+		ID:         1,
+		Res:        entry.Response{StartedEvent: 1},
+		ID2History: make(map[int][]element.History),
+		Gw2Picks:   make(map[int]entry.PicksResponse),
+		History:    entry.HistoryResponse{Chips: chips},
+		Transfers:  transfers,
+	}
+	for id := 1; id <= 9; id++ {
+		for gw := 1; gw <= 3; gw++ {
+			e.ID2History[id] = append(e.ID2History[id], element.History{PlayerID: id, Round: gw, Value: 40 + id + gw - 1})
+		}
+	}
+	for gw, squad := range gw2Squad {
+		picks := entry.PicksResponse{EntryHistory: entry.GameweekHistory{Event: gw, Bank: gw2Bank[gw]}}
+		for i, id := range squad {
+			picks.Picks = append(picks.Picks, entry.Pick{Element: id, Position: i + 1})
+		}
+		for _, chip := range chips {
+			if chip.Event == gw {
+				picks.ActiveChip = chip.Name
+			}
+		}
+		e.Gw2Picks[gw] = picks
+		e.History.Current = append(e.History.Current, picks.EntryHistory)
+	}
+	return e
+}
+
+func TestFromEntry(t *testing.T) {
+	tests := []struct {
+		name              string
+		e                 *entry.Entry
+		gw                int
+		wantSquad         []int
+		wantBank          int
+		wantPurchasePrice map[int]int
+		wantErr           bool
+	}{
+		{
+			name:              "initial squad",
+			e:                 testEntry(map[int][]int{1: {1, 2, 3}, 2: {1, 2, 3}}, map[int]int{1: 5, 2: 5}, nil, nil),
+			gw:                2,
+			wantSquad:         []int{1, 2, 3},
+			wantBank:          5,
+			wantPurchasePrice: map[int]int{1: 41, 2: 42, 3: 43},
+		},
+		{
+			name: "latest transfer in",
+			e: testEntry(map[int][]int{1: {1, 2, 3}, 2: {1, 2, 4}, 3: {1, 2, 4}}, map[int]int{1: 5, 2: 3, 3: 3}, []entry.Transfer{
+				{ElementIn: 4, ElementInCost: 47, ElementOut: 3, Event: 2},
+				{ElementIn: 3, ElementInCost: 60, ElementOut: 1, Event: 4},
+			}, nil),
+			gw:                3,
+			wantSquad:         []int{1, 2, 4},
+			wantBank:          3,
+			wantPurchasePrice: map[int]int{1: 41, 2: 42, 4: 47},
+		},
+		{
+			name: "free hit transfers undone",
+			e: testEntry(map[int][]int{1: {1, 2, 3}, 2: {1, 5, 6}, 3: {1, 2, 3}}, map[int]int{1: 5, 2: 0, 3: 5}, []entry.Transfer{
+				{ElementIn: 5, ElementInCost: 55, ElementOut: 2, Event: 2},
+				{ElementIn: 6, ElementInCost: 56, ElementOut: 3, Event: 2},
+			}, []entry.Chip{{Name: entry.FreeHit, Event: 2}}),
+			gw:                3,
+			wantSquad:         []int{1, 2, 3},
+			wantBank:          5,
+			wantPurchasePrice: map[int]int{1: 41, 2: 42, 3: 43},
+		},
+		{
+			name: "squad and bank before a free hit in gw",
+			e: testEntry(map[int][]int{1: {1, 2, 3}, 2: {1, 5, 6}}, map[int]int{1: 5, 2: 0}, []entry.Transfer{
+				{ElementIn: 5, ElementInCost: 55, ElementOut: 2, Event: 2},
+				{ElementIn: 6, ElementInCost: 56, ElementOut: 3, Event: 2},
+			}, []entry.Chip{{Name: entry.FreeHit, Event: 2}}),
+			gw:                2,
+			wantSquad:         []int{1, 2, 3},
+			wantBank:          5,
+			wantPurchasePrice: map[int]int{1: 41, 2: 42, 3: 43},
+		},
+		{
+			name:    "gameweek not fetched",
+			e:       testEntry(map[int][]int{1: {1, 2, 3}}, nil, nil, nil),
+			gw:      2,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := planner.FromEntry(tt.e, tt.gw, planner.DefaultMaxFreeTransfers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromEntry() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(s.Squad, tt.wantSquad) {
+				t.Errorf("Squad = %v, want %v", s.Squad, tt.wantSquad)
+			}
+			if s.Bank != tt.wantBank {
+				t.Errorf("Bank = %d, want %d", s.Bank, tt.wantBank)
+			}
+			if !reflect.DeepEqual(s.PurchasePrice, tt.wantPurchasePrice) {
+				t.Errorf("PurchasePrice = %v, want %v", s.PurchasePrice, tt.wantPurchasePrice)
+			}
+		})
+	}
+}
+
+func TestSellingPrice(t *testing.T) {
+	tests := []struct {
+		purchase, now int
+		want          int
+	}{
+		{purchase: 50, now: 50, want: 50},
+		{purchase: 50, now: 51, want: 50},
+		{purchase: 50, now: 52, want: 51},
+		{purchase: 50, now: 55, want: 52},
+		{purchase: 50, now: 45, want: 45},
+	}
+	for _, tt := range tests {
+		if got := planner.SellingPrice(tt.purchase, tt.now, 0.5); got != tt.want {
+			t.Errorf("SellingPrice(%d, %d) = %d, want %d", tt.purchase, tt.now, got, tt.want)
+		}
+	}
+}
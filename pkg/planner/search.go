@@ -0,0 +1,263 @@
+package planner
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/optimizer"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// search state of Plans, with projections cached per player and gameweek index
+type search struct {
+	Planner
+	id2Player map[int]team.Player
+	gameweeks []int
+	proj      map[int][]float64
+	remaining map[int][]float64
+	pool      map[int][]int
+}
+
+// move of a single transfer
+type move struct {
+	out, in int
+}
+
+// fillPool with projections of every player and the buyable players per position:
+// the Pool best by remaining projected points and the Pool best per cost
+func (s *search) fillPool() {
+	s.proj = make(map[int][]float64, len(s.Players))
+	s.remaining = make(map[int][]float64, len(s.Players))
+	role2Players := make(map[int][]team.Player)
+	for _, p := range s.Players {
+		proj := make([]float64, len(s.gameweeks))
+		remaining := make([]float64, len(s.gameweeks)+1)
+		for i, gw := range s.gameweeks {
+			proj[i] = s.Projection(p.ID, gw)
+		}
+		for i := len(s.gameweeks) - 1; i >= 0; i-- {
+			remaining[i] = remaining[i+1] + proj[i]
+		}
+		s.proj[p.ID], s.remaining[p.ID] = proj, remaining
+		role2Players[p.RoleID] = append(role2Players[p.RoleID], p)
+	}
+
+	s.pool = make(map[int][]int)
+	for roleID, players := range role2Players {
+		inPool := make(map[int]bool)
+		for _, value := range []func(p team.Player) float64{
+			func(p team.Player) float64 { return s.remaining[p.ID][0] },
+			func(p team.Player) float64 { return s.remaining[p.ID][0] / float64(p.NowCost+1) },
+		} {
+			sort.SliceStable(players, func(i, j int) bool { return value(players[i]) > value(players[j]) })
+			for i := 0; i < len(players) && i < s.Pool; i++ {
+				if !inPool[players[i].ID] {
+					inPool[players[i].ID] = true
+					s.pool[roleID] = append(s.pool[roleID], players[i].ID)
+				}
+			}
+		}
+	}
+}
+
+// points of squad in the gameweek at index i: its best XI with the top starter as captain
+func (s *search) points(squad []int, i int) float64 {
+	candidates := make([]optimizer.Candidate, len(squad))
+	for j, id := range squad {
+		p := s.id2Player[id]
+		candidates[j] = optimizer.Candidate{ID: id, TeamID: p.TeamID, RoleID: p.RoleID, Cost: p.NowCost, Score: s.proj[id][i]}
+	}
+	lineup := optimizer.Optimizer{Rules: s.Rules}.Lineup(candidates)
+	captain := 0.0
+	for _, pick := range lineup.Picks {
+		if pick.Starter && pick.Score > captain {
+			captain = pick.Score
+		}
+	}
+	return lineup.XIScore + captain
+}
+
+// roll points of start over every gameweek without a transfer
+func (s *search) roll(start State) (points float64) {
+	for i := range s.gameweeks {
+		points += s.points(start.Squad, i)
+	}
+	return points
+}
+
+// step the beam through the gameweek at index i, keeping the BeamWidth best plans
+// of distinct states
+func (s *search) step(beam []node, i int) []node {
+	gw := s.gameweeks[i]
+	key2Node := make(map[string]node)
+	for _, nd := range beam {
+		for _, moves := range s.moves(nd.state, i) {
+			next := s.apply(nd.state, moves)
+			hits := 0
+			if extra := len(moves) - nd.state.FreeTransfers; extra > 0 {
+				hits = extra * s.HitCost
+			}
+			next.FreeTransfers = nd.state.FreeTransfers - len(moves)
+			if next.FreeTransfers < 0 {
+				next.FreeTransfers = 0
+			}
+			next.FreeTransfers++
+			if next.FreeTransfers > s.MaxFreeTransfers {
+				next.FreeTransfers = s.MaxFreeTransfers
+			}
+
+			points := s.points(next.Squad, i) - float64(hits)
+			plan := Plan{
+				Transfers: append([]Transfer{}, nd.plan.Transfers...),
+				Hits:      nd.plan.Hits + hits,
+				Points:    nd.plan.Points + points,
+				Gw2Points: make(map[int]float64, len(nd.plan.Gw2Points)+1),
+			}
+			for k, v := range nd.plan.Gw2Points {
+				plan.Gw2Points[k] = v
+			}
+			plan.Gw2Points[gw] = points
+			for _, m := range moves {
+				plan.Transfers = append(plan.Transfers, Transfer{
+					Gameweek:  gw,
+					Out:       m.out,
+					In:        m.in,
+					SellPrice: s.sell(nd.state, m.out),
+					BuyPrice:  s.id2Player[m.in].NowCost,
+				})
+			}
+			key := s.key(next)
+			if existing, ok := key2Node[key]; !ok || plan.Points > existing.plan.Points {
+				key2Node[key] = node{state: next, plan: plan}
+			}
+		}
+	}
+
+	nodes := make([]node, 0, len(key2Node))
+	for _, nd := range key2Node {
+		nodes = append(nodes, nd)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].plan.Points != nodes[j].plan.Points {
+			return nodes[i].plan.Points > nodes[j].plan.Points
+		}
+		if len(nodes[i].plan.Transfers) != len(nodes[j].plan.Transfers) {
+			return len(nodes[i].plan.Transfers) < len(nodes[j].plan.Transfers)
+		}
+		return s.key(nodes[i].state) < s.key(nodes[j].state)
+	})
+	if len(nodes) > s.BeamWidth {
+		nodes = nodes[:s.BeamWidth]
+	}
+	return nodes
+}
+
+// moves from st before the gameweek at index i: none, every valid single and,
+// when MaxTransfers allows, valid pairs of the Pool singles with the best remaining gain
+func (s *search) moves(st State, i int) [][]move {
+	inSquad := make(map[int]bool, len(st.Squad))
+	for _, id := range st.Squad {
+		inSquad[id] = true
+	}
+	type single struct {
+		move
+		gain float64
+	}
+	singles := []single{}
+	for _, out := range st.Squad {
+		for _, in := range s.pool[s.id2Player[out].RoleID] {
+			m := move{out: out, in: in}
+			if inSquad[in] || !s.fits(st, m) {
+				continue
+			}
+			singles = append(singles, single{move: m, gain: s.remaining[in][i] - s.remaining[out][i]})
+		}
+	}
+	sort.SliceStable(singles, func(a, b int) bool { return singles[a].gain > singles[b].gain })
+
+	res := [][]move{nil}
+	for _, m := range singles {
+		res = append(res, []move{m.move})
+	}
+	if s.MaxTransfers < 2 {
+		return res
+	}
+	if len(singles) > s.Pool {
+		singles = singles[:s.Pool]
+	}
+	for a := range singles {
+		for b := a + 1; b < len(singles); b++ {
+			m1, m2 := singles[a].move, singles[b].move
+			if m1.out == m2.out || m1.in == m2.in || !s.fits(st, m1, m2) {
+				continue
+			}
+			res = append(res, []move{m1, m2})
+		}
+	}
+	return res
+}
+
+// fits when moves keep the bank non-negative and every club within the limit
+func (s *search) fits(st State, moves ...move) bool {
+	bank := st.Bank
+	teams := make(map[int]int)
+	for _, id := range st.Squad {
+		teams[s.id2Player[id].TeamID]++
+	}
+	for _, m := range moves {
+		bank += s.sell(st, m.out) - s.id2Player[m.in].NowCost
+		teams[s.id2Player[m.out].TeamID]--
+		teams[s.id2Player[m.in].TeamID]++
+	}
+	if bank < 0 {
+		return false
+	}
+	for _, n := range teams {
+		if n > s.Rules.TeamLimit {
+			return false
+		}
+	}
+	return true
+}
+
+// sell price of player id from st
+func (s *search) sell(st State, id int) int {
+	return st.SellingPrice(id, s.id2Player[id].NowCost, s.SellOnFee)
+}
+
+// apply moves to st, bought players are purchased at their current price
+func (s *search) apply(st State, moves []move) State {
+	next := State{
+		Squad:         append([]int{}, st.Squad...),
+		PurchasePrice: make(map[int]int, len(st.PurchasePrice)+len(moves)),
+		Bank:          st.Bank,
+		FreeTransfers: st.FreeTransfers,
+	}
+	for id, price := range st.PurchasePrice {
+		next.PurchasePrice[id] = price
+	}
+	for _, m := range moves {
+		for j, id := range next.Squad {
+			if id == m.out {
+				next.Squad[j] = m.in
+			}
+		}
+		next.Bank += s.sell(st, m.out) - s.id2Player[m.in].NowCost
+		delete(next.PurchasePrice, m.out)
+		next.PurchasePrice[m.in] = s.id2Player[m.in].NowCost
+	}
+	return next
+}
+
+// key of a state regardless of squad order
+func (s *search) key(st State) string {
+	ids := append([]int{}, st.Squad...)
+	sort.Ints(ids)
+	parts := make([]string, 0, len(ids)+2)
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	parts = append(parts, "free"+strconv.Itoa(st.FreeTransfers), "bank"+strconv.Itoa(st.Bank))
+	return strings.Join(parts, ",")
+}
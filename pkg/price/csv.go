@@ -0,0 +1,70 @@
+package price
+
+import (
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+)
+
+// ChangeRow of a Change for csv.StructSlice, Direction is rise or fall
+type ChangeRow struct {
+	Player    string
+	From      string
+	To        string
+	OldCost   int
+	NewCost   int
+	Direction string
+}
+
+// PredictionRow of a Prediction for csv.StructSlice, Progress in percent, Tonight is
+// rise or fall once it reaches 100 either way
+type PredictionRow struct {
+	Player       string
+	Position     string
+	NowCost      int
+	Owners       int
+	NetTransfers int
+	Progress     float64
+	Tonight      string
+}
+
+// ToCsv price changes between snapshots and the predicted next ones
+func (pr Predictor) ToCsv(snapshots []Snapshot, r *registry.Registry) error {
+	changeRows := []ChangeRow{}
+	for _, c := range Changes(snapshots) {
+		direction := "rise"
+		if c.NewCost < c.OldCost {
+			direction = "fall"
+		}
+		changeRows = append(changeRows, ChangeRow{
+			Player:    r.PlayerName(c.PlayerID),
+			From:      c.From,
+			To:        c.To,
+			OldCost:   c.OldCost,
+			NewCost:   c.NewCost,
+			Direction: direction,
+		})
+	}
+	if err := csv.StructSlice(changeRows, "fpl-price-changes"); err != nil {
+		return err
+	}
+
+	predictionRows := []PredictionRow{}
+	for _, p := range pr.Predict(snapshots) {
+		row := PredictionRow{
+			Player:       r.PlayerName(p.PlayerID),
+			Position:     r.PlayerPosition(p.PlayerID),
+			NowCost:      p.NowCost,
+			Owners:       p.Owners,
+			NetTransfers: p.NetTransfers,
+			Progress:     p.Progress * 100,
+		}
+		switch {
+		case p.Progress >= 1:
+			row.Tonight = "rise"
+		case p.Progress <= -1:
+			row.Tonight = "fall"
+		}
+		predictionRows = append(predictionRows, row)
+	}
+	return csv.StructSlice(predictionRows, "fpl-price-predictions")
+}
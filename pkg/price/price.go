@@ -0,0 +1,240 @@
+// Package price provides daily snapshots of player prices and transfers, the rises and
+// falls between them and the progress of every player toward its next change
+package price
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/fpl"
+)
+
+// DefaultThreshold of net transfers per owner of a zero Predictor that moves a price.
+// The game keeps its algorithm private: price trackers see changes after net transfers
+// of roughly a tenth of a player's owners, so this is an estimate to tune with Changes.
+const DefaultThreshold = 0.1
+
+// DefaultMinOwners of a zero Predictor, the owners a player counts as at least so that
+// a handful of transfers into a barely owned player does not predict a rise. Around
+// 0.1% of a season's ten million or so managers, trackers seeing no rise for less.
+const DefaultMinOwners = 10000
+
+// dateLayout of snapshot file names, one snapshot per day
+const dateLayout = "2006-01-02"
+
+// Snapshot of every player's price and transfers on Date during gameweek Event
+type Snapshot struct {
+	Date         string    `json:"date"`
+	TakenAt      time.Time `json:"taken_at"`
+	Event        int       `json:"event"`
+	TotalPlayers int       `json:"total_players"`
+	Players      []Player  `json:"players"`
+}
+
+// Player price and transfers of the current gameweek in a Snapshot
+type Player struct {
+	ID                int     `json:"id"`
+	NowCost           int     `json:"now_cost"`
+	CostChangeEvent   int     `json:"cost_change_event"`
+	CostChangeStart   int     `json:"cost_change_start"`
+	TransfersInEvent  int     `json:"transfers_in_event"`
+	TransfersOutEvent int     `json:"transfers_out_event"`
+	SelectedByPercent float64 `json:"selected_by_percent"`
+}
+
+// Change of a player's price between the snapshots of From and To
+type Change struct {
+	PlayerID int
+	From     string
+	To       string
+	OldCost  int
+	NewCost  int
+}
+
+// Prediction of a player's next change. NetTransfers are counted since its last change
+// seen in the snapshots, Progress is NetTransfers over the threshold of its owners:
+// a rise is due at 1 and a fall at -1.
+type Prediction struct {
+	PlayerID     int
+	NowCost      int
+	Owners       int
+	NetTransfers int
+	Progress     float64
+}
+
+// Store of daily snapshots as json files in Dir
+type Store struct {
+	Dir string
+}
+
+// Predictor of price changes from the snapshots so far, with the Threshold of net
+// transfers per owner and the MinOwners floor, DefaultThreshold and DefaultMinOwners when 0
+type Predictor struct {
+	Threshold float64
+	MinOwners int
+}
+
+// FromResponse snapshot of bootstrap-static taken at now
+func FromResponse(res fpl.Response, now time.Time) Snapshot {
+	s := Snapshot{
+		Date:         now.Format(dateLayout),
+		TakenAt:      now,
+		TotalPlayers: res.TotalPlayers,
+		Players:      make([]Player, len(res.Players)),
+	}
+	for _, event := range res.Events {
+		if event.IsCurrent {
+			s.Event = event.ID
+		}
+	}
+	for i, p := range res.Players {
+		selected, _ := strconv.ParseFloat(p.SelectedByPercent, 64)
+		s.Players[i] = Player{
+			ID:                p.ID,
+			NowCost:           p.NowCost,
+			CostChangeEvent:   p.CostChangeEvent,
+			CostChangeStart:   p.CostChangeStart,
+			TransfersInEvent:  p.TransfersInEvent,
+			TransfersOutEvent: p.TransfersOutEvent,
+			SelectedByPercent: selected,
+		}
+	}
+	return s
+}
+
+// Save s as the snapshot of its date, replacing an earlier one of the same day
+func (st Store) Save(s Snapshot) error {
+	if err := os.MkdirAll(st.Dir, 0755); err != nil {
+		return err
+	}
+	snapshotBytes, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(st.Dir, s.Date+".json"), snapshotBytes, 0644)
+}
+
+// Load every snapshot, oldest first, none when Dir does not exist yet
+func (st Store) Load() ([]Snapshot, error) {
+	infos, err := ioutil.ReadDir(st.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshots := []Snapshot{}
+	for _, info := range infos {
+		date := strings.TrimSuffix(info.Name(), ".json")
+		if info.IsDir() || date == info.Name() {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			continue
+		}
+		snapshotBytes, err := ioutil.ReadFile(filepath.Join(st.Dir, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		s := Snapshot{}
+		if err := json.Unmarshal(snapshotBytes, &s); err != nil {
+			return nil, fmt.Errorf("snapshot %v: %w", info.Name(), err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Date < snapshots[j].Date })
+	return snapshots, nil
+}
+
+// Changes of price between consecutive snapshots, by date then player
+func Changes(snapshots []Snapshot) []Change {
+	changes := []Change{}
+	for i := 1; i < len(snapshots); i++ {
+		prev, cur := snapshots[i-1], snapshots[i]
+		id2Cost := make(map[int]int, len(prev.Players))
+		for _, p := range prev.Players {
+			id2Cost[p.ID] = p.NowCost
+		}
+		for _, p := range cur.Players {
+			if cost, ok := id2Cost[p.ID]; ok && cost != p.NowCost {
+				changes = append(changes, Change{PlayerID: p.ID, From: prev.Date, To: cur.Date, OldCost: cost, NewCost: p.NowCost})
+			}
+		}
+	}
+	return changes
+}
+
+// Predict the next change of every player in the latest snapshot, likeliest rises first
+// and likeliest falls last. Net transfers accumulate over the snapshots since a player's
+// last change, counters restarting with every gameweek.
+func (pr Predictor) Predict(snapshots []Snapshot) []Prediction {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	threshold := pr.Threshold
+	if threshold == 0 {
+		threshold = DefaultThreshold
+	}
+	minOwners := pr.MinOwners
+	if minOwners == 0 {
+		minOwners = DefaultMinOwners
+	}
+	// id2Players of each snapshot by player ID
+	id2Players := make([]map[int]Player, len(snapshots))
+	for i, s := range snapshots {
+		id2Players[i] = make(map[int]Player, len(s.Players))
+		for _, p := range s.Players {
+			id2Players[i][p.ID] = p
+		}
+	}
+
+	latest := snapshots[len(snapshots)-1]
+	predictions := make([]Prediction, 0, len(latest.Players))
+	for _, p := range latest.Players {
+		pred := Prediction{
+			PlayerID: p.ID,
+			NowCost:  p.NowCost,
+			Owners:   int(math.Round(p.SelectedByPercent / 100 * float64(latest.TotalPlayers))),
+		}
+		// first snapshot k at the current price, the change happened just before it
+		k, changed := len(snapshots)-1, false
+		for ; k > 0; k-- {
+			prev, ok := id2Players[k-1][p.ID]
+			if ok && prev.NowCost != p.NowCost {
+				changed = true
+			}
+			if !ok || changed {
+				break
+			}
+		}
+		if !changed {
+			// no change seen, count from the start of the gameweek of k
+			pred.NetTransfers = net(id2Players[k][p.ID])
+		}
+		for i := k + 1; i < len(snapshots); i++ {
+			cur, prev := id2Players[i][p.ID], id2Players[i-1][p.ID]
+			if snapshots[i].Event == snapshots[i-1].Event {
+				pred.NetTransfers += net(cur) - net(prev)
+			} else {
+				pred.NetTransfers += net(cur)
+			}
+		}
+		pred.Progress = float64(pred.NetTransfers) / (threshold * math.Max(float64(pred.Owners), float64(minOwners)))
+		predictions = append(predictions, pred)
+	}
+	sort.SliceStable(predictions, func(i, j int) bool { return predictions[i].Progress > predictions[j].Progress })
+	return predictions
+}
+
+// net transfers of p in the current gameweek
+func net(p Player) int {
+	return p.TransfersInEvent - p.TransfersOutEvent
+}
@@ -0,0 +1,117 @@
+package price_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/price"
+)
+
+// day snapshot of one player 1 in gameweek event, a million managers
+func day(date string, event, nowCost int, selectedByPercent float64, transfersIn, transfersOut int) price.Snapshot {
+	return price.Snapshot{
+		Date:         date,
+		Event:        event,
+		TotalPlayers: 1000000,
+		Players: []price.Player{{
+			ID:                1,
+			NowCost:           nowCost,
+			SelectedByPercent: selectedByPercent,
+			TransfersInEvent:  transfersIn,
+			TransfersOutEvent: transfersOut,
+		}},
+	}
+}
+
+func TestPredict(t *testing.T) {
+	tests := []struct {
+		name             string
+		predictor        price.Predictor
+		snapshots        []price.Snapshot
+		wantNetTransfers int
+		wantProgress     float64
+	}{
+		{
+			// 100000 owners need 10000 net transfers
+			name:             "crossing +100%",
+			snapshots:        []price.Snapshot{day("2026-08-01", 1, 50, 10, 6000, 0), day("2026-08-02", 1, 50, 10, 12000, 0)},
+			wantNetTransfers: 12000,
+			wantProgress:     1.2,
+		},
+		{
+			name:             "crossing -100%",
+			snapshots:        []price.Snapshot{day("2026-08-01", 1, 50, 10, 0, 11000)},
+			wantNetTransfers: -11000,
+			wantProgress:     -1.1,
+		},
+		{
+			name: "reset after a price change",
+			snapshots: []price.Snapshot{
+				day("2026-08-01", 1, 50, 10, 8000, 0),
+				day("2026-08-02", 1, 51, 10, 11000, 0),
+				day("2026-08-03", 1, 51, 10, 11500, 0),
+			},
+			wantNetTransfers: 500,
+			wantProgress:     0.05,
+		},
+		{
+			name:             "counters restart with the gameweek",
+			snapshots:        []price.Snapshot{day("2026-08-01", 1, 50, 10, 3000, 0), day("2026-08-02", 2, 50, 10, 1000, 0)},
+			wantNetTransfers: 4000,
+			wantProgress:     0.4,
+		},
+		{
+			// 1000 owners count as 10000
+			name:             "below the owner floor",
+			snapshots:        []price.Snapshot{day("2026-08-01", 1, 50, 0.1, 500, 0)},
+			wantNetTransfers: 500,
+			wantProgress:     0.5,
+		},
+		{
+			name:             "lower owner floor",
+			predictor:        price.Predictor{MinOwners: 1000},
+			snapshots:        []price.Snapshot{day("2026-08-01", 1, 50, 0.1, 500, 0)},
+			wantNetTransfers: 500,
+			wantProgress:     5,
+		},
+		{
+			name:             "higher threshold",
+			predictor:        price.Predictor{Threshold: 0.2},
+			snapshots:        []price.Snapshot{day("2026-08-01", 1, 50, 10, 12000, 0)},
+			wantNetTransfers: 12000,
+			wantProgress:     0.6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predictions := tt.predictor.Predict(tt.snapshots)
+			if len(predictions) != 1 {
+				t.Fatalf("Predict() = %+v, want one prediction", predictions)
+			}
+			got := predictions[0]
+			if got.NetTransfers != tt.wantNetTransfers || math.Abs(got.Progress-tt.wantProgress) > 1e-9 {
+				t.Errorf("Predict() = %+v, want NetTransfers %d and Progress %v", got, tt.wantNetTransfers, tt.wantProgress)
+			}
+		})
+	}
+	if got := (price.Predictor{}).Predict(nil); got != nil {
+		t.Errorf("Predict() without snapshots = %v, want nil", got)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	snapshots := []price.Snapshot{
+		day("2026-08-01", 1, 50, 10, 0, 0),
+		day("2026-08-02", 1, 51, 10, 0, 0),
+		day("2026-08-03", 1, 51, 10, 0, 0),
+		day("2026-08-04", 2, 50, 10, 0, 0),
+	}
+	want := []price.Change{
+		{PlayerID: 1, From: "2026-08-01", To: "2026-08-02", OldCost: 50, NewCost: 51},
+		{PlayerID: 1, From: "2026-08-03", To: "2026-08-04", OldCost: 51, NewCost: 50},
+	}
+	if got := price.Changes(snapshots); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package projection
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+)
+
+// Row of a Projection for csv.StructSlice, EpNext of the api alongside for comparison
+type Row struct {
+	PlayerID  int
+	WebName   string
+	TeamName  string
+	RoleName  string
+	Gameweek  int
+	Opponents string
+	Minutes   float64
+	Points    float64
+	EpNext    string
+}
+
+// Rows of Projections over gameweeks, opponents read like the ticker's "CHE(H) ars(A)"
+func (m *Model) Rows(gameweeks []int) []Row {
+	id2EpNext := make(map[int]string, len(m.Players))
+	for _, p := range m.Players {
+		id2EpNext[p.ID] = p.EpNext
+	}
+	rows := []Row{}
+	for _, proj := range m.Projections(gameweeks) {
+		teamID := m.id2Rates[proj.PlayerID].teamID
+		opponents := []string{}
+		if m.Fixtures != nil {
+			for _, f := range m.Fixtures.Team2Gw2Fixture[teamID][proj.Gameweek] {
+				_, opponent, isHome := fixtures.Difficulty(f, teamID)
+				if isHome {
+					opponents = append(opponents, fmt.Sprintf("%v(H)", strings.ToUpper(m.Registry.TeamName(opponent))))
+				} else {
+					opponents = append(opponents, fmt.Sprintf("%v(A)", strings.ToLower(m.Registry.TeamName(opponent))))
+				}
+			}
+		}
+		row := Row{
+			PlayerID:  proj.PlayerID,
+			WebName:   m.Registry.PlayerName(proj.PlayerID),
+			TeamName:  m.Registry.TeamName(teamID),
+			RoleName:  m.Registry.PositionName(m.id2Rates[proj.PlayerID].roleID),
+			Gameweek:  proj.Gameweek,
+			Opponents: strings.Join(opponents, " "),
+			Minutes:   proj.Minutes,
+			Points:    proj.Points,
+		}
+		if len(gameweeks) > 0 && proj.Gameweek == gameweeks[0] {
+			row.EpNext = id2EpNext[proj.PlayerID]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ToCsv projections of every player over gameweeks
+func (m *Model) ToCsv(gameweeks []int) error {
+	return csv.StructSlice(m.Rows(gameweeks), "fpl-projections")
+}
@@ -0,0 +1,241 @@
+// Package projection provides expected points per player and upcoming gameweek from
+// element-summary per-90 rates, minutes likelihood, opponent strength and home/away
+package projection
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/element"
+	"github.com/jadugnap/golang-fpl-101/pkg/fixtures"
+	"github.com/jadugnap/golang-fpl-101/pkg/registry"
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+)
+
+// DefaultRecent matches behind the minutes likelihood of a zero Model
+const DefaultRecent = 6
+
+// priorMatches of the position average mixed into every per-90 rate, so that
+// a few lucky minutes do not make a striker of a substitute
+const priorMatches = 3.0
+
+// points per event by element_type, as scored by the official rules
+var (
+	goalPoints       = map[int]float64{1: 6, 2: 6, 3: 5, 4: 4}
+	cleanSheetPoints = map[int]float64{1: 4, 2: 4, 3: 1}
+	concededPoints   = map[int]float64{1: -0.5, 2: -0.5}
+)
+
+const (
+	assistPoints = 3.0
+	savePoints   = 1.0 / 3
+)
+
+// Model of expected points, fitted on ID2History before gameweek AsOf (every gameweek
+// when 0) so that a backtest never looks ahead. Teams' strengths scale attack and
+// defence per fixture, Fixtures give the opponents and blank or double gameweeks.
+type Model struct {
+	Players    []team.Player
+	Teams      []team.Team
+	ID2History map[int][]element.History
+	Fixtures   *fixtures.Fixtures
+	Registry   *registry.Registry
+	AsOf       int
+	Recent     int
+
+	id2Rates map[int]rates
+	id2Team  map[int]team.Team
+	avgAtt   float64
+	avgDef   float64
+}
+
+// rates of a player per 90 minutes, with the chance to play and to play 60+
+// and the expected minutes from the Recent matches
+type rates struct {
+	roleID   int
+	teamID   int
+	goals    float64
+	assists  float64
+	bonus    float64
+	saves    float64
+	conceded float64
+	other    float64
+	pPlay    float64
+	p60      float64
+	minutes  float64
+}
+
+// Projection of a player in a gameweek, summed over its fixtures
+type Projection struct {
+	PlayerID int
+	Gameweek int
+	Fixtures int
+	Minutes  float64
+	Points   float64
+}
+
+// Fit per-90 rates and team strengths, again after changing AsOf or the data
+func (m *Model) Fit() {
+	recent := m.Recent
+	if recent == 0 {
+		recent = DefaultRecent
+	}
+	m.id2Team = make(map[int]team.Team, len(m.Teams))
+	m.avgAtt, m.avgDef = 0, 0
+	for _, t := range m.Teams {
+		m.id2Team[t.ID] = t
+		m.avgAtt += float64(t.StrengthAttackHome+t.StrengthAttackAway) / 2
+		m.avgDef += float64(t.StrengthDefenceHome+t.StrengthDefenceAway) / 2
+	}
+	if len(m.Teams) > 0 {
+		m.avgAtt /= float64(len(m.Teams))
+		m.avgDef /= float64(len(m.Teams))
+	}
+
+	// totals per player and per position for the prior
+	type totals struct {
+		minutes                                       float64
+		goals, assists, bonus, saves, conceded, other float64
+	}
+	id2Totals := make(map[int]totals, len(m.Players))
+	role2Totals := make(map[int]totals)
+	id2Recent := make(map[int][]element.History, len(m.Players))
+	for _, p := range m.Players {
+		t := totals{}
+		past := []element.History{}
+		for _, h := range m.ID2History[p.ID] {
+			if m.AsOf > 0 && h.Round >= m.AsOf {
+				continue
+			}
+			past = append(past, h)
+			t.minutes += float64(h.Minutes)
+			t.goals += float64(h.GoalsScored)
+			t.assists += float64(h.Assists)
+			t.bonus += float64(h.Bonus)
+			t.saves += float64(h.Saves)
+			t.conceded += float64(h.GoalsConceded)
+			t.other += float64(5*h.PenaltiesSaved - 2*h.PenaltiesMissed - 2*h.OwnGoals - h.YellowCards - 3*h.RedCards)
+		}
+		sort.SliceStable(past, func(i, j int) bool { return past[i].Round < past[j].Round })
+		if len(past) > recent {
+			past = past[len(past)-recent:]
+		}
+		id2Totals[p.ID], id2Recent[p.ID] = t, past
+		r := role2Totals[p.RoleID]
+		r.minutes += t.minutes
+		r.goals += t.goals
+		r.assists += t.assists
+		r.bonus += t.bonus
+		r.saves += t.saves
+		r.conceded += t.conceded
+		r.other += t.other
+		role2Totals[p.RoleID] = r
+	}
+
+	m.id2Rates = make(map[int]rates, len(m.Players))
+	for _, p := range m.Players {
+		t, prior := id2Totals[p.ID], role2Totals[p.RoleID]
+		per90 := func(value, priorValue float64) float64 {
+			priorRate := 0.0
+			if prior.minutes > 0 {
+				priorRate = priorValue / prior.minutes * 90
+			}
+			return (value + priorRate*priorMatches) / (t.minutes/90 + priorMatches)
+		}
+		r := rates{
+			roleID:   p.RoleID,
+			teamID:   p.TeamID,
+			goals:    per90(t.goals, prior.goals),
+			assists:  per90(t.assists, prior.assists),
+			bonus:    per90(t.bonus, prior.bonus),
+			saves:    per90(t.saves, prior.saves),
+			conceded: per90(t.conceded, prior.conceded),
+			other:    per90(t.other, prior.other),
+		}
+		if past := id2Recent[p.ID]; len(past) > 0 {
+			for _, h := range past {
+				if h.Minutes > 0 {
+					r.pPlay++
+				}
+				if h.Minutes >= 60 {
+					r.p60++
+				}
+				r.minutes += float64(h.Minutes)
+			}
+			n := float64(len(past))
+			r.pPlay, r.p60, r.minutes = r.pPlay/n, r.p60/n, r.minutes/n
+		}
+		m.id2Rates[p.ID] = r
+	}
+}
+
+// factors of attack and defence of teamID against opponent, 1 is an average fixture.
+// Attack above 1 means more goals scored, defence above 1 more goals conceded.
+func (m *Model) factors(teamID, opponent int, isHome bool) (attack, defence float64) {
+	own, opp := m.id2Team[teamID], m.id2Team[opponent]
+	ownAtt, ownDef := own.StrengthAttackAway, own.StrengthDefenceAway
+	oppAtt, oppDef := opp.StrengthAttackHome, opp.StrengthDefenceHome
+	if isHome {
+		ownAtt, ownDef = own.StrengthAttackHome, own.StrengthDefenceHome
+		oppAtt, oppDef = opp.StrengthAttackAway, opp.StrengthDefenceAway
+	}
+	if ownAtt == 0 || ownDef == 0 || oppAtt == 0 || oppDef == 0 {
+		return 1, 1
+	}
+	attack = float64(ownAtt) / m.avgAtt * m.avgDef / float64(oppDef)
+	defence = float64(oppAtt) / m.avgAtt * m.avgDef / float64(ownDef)
+	return attack, defence
+}
+
+// fixturePoints expected of r against opponent
+func (m *Model) fixturePoints(r rates, opponent int, isHome bool) float64 {
+	attack, defence := m.factors(r.teamID, opponent, isHome)
+	share := r.minutes / 90
+	points := 2*r.p60 + (r.pPlay - r.p60)
+	points += share * (r.goals*goalPoints[r.roleID] + r.assists*assistPoints + r.bonus) * attack
+	points += share * (r.saves*savePoints + r.conceded*concededPoints[r.roleID]) * defence
+	points += share * r.other
+	points += r.p60 * cleanSheetPoints[r.roleID] * math.Exp(-r.conceded*defence)
+	return points
+}
+
+// Project expected points of player id in gameweek gw, 0 on a blank, summed on a double
+func (m *Model) Project(id, gw int) float64 {
+	return m.projection(id, gw).Points
+}
+
+func (m *Model) projection(id, gw int) Projection {
+	if m.id2Rates == nil {
+		m.Fit()
+	}
+	r, ok := m.id2Rates[id]
+	res := Projection{PlayerID: id, Gameweek: gw}
+	if !ok || m.Fixtures == nil {
+		return res
+	}
+	for _, f := range m.Fixtures.Team2Gw2Fixture[r.teamID][gw] {
+		_, opponent, isHome := fixtures.Difficulty(f, r.teamID)
+		res.Fixtures++
+		res.Minutes += r.minutes
+		res.Points += m.fixturePoints(r, opponent, isHome)
+	}
+	return res
+}
+
+// Projections of every player over gameweeks, by player then gameweek
+func (m *Model) Projections(gameweeks []int) []Projection {
+	res := make([]Projection, 0, len(m.Players)*len(gameweeks))
+	for _, p := range m.Players {
+		for _, gw := range gameweeks {
+			res = append(res, m.projection(p.ID, gw))
+		}
+	}
+	return res
+}
+
+// EpNext of player p as published by the api, 0 when missing
+func EpNext(p team.Player) float64 {
+	ep, _ := strconv.ParseFloat(p.EpNext, 64)
+	return ep
+}
@@ -0,0 +1,71 @@
+// Package registry provides runtime name lookups built from bootstrap-static
+package registry
+
+import (
+	"github.com/jadugnap/golang-fpl-101/pkg/team"
+	"github.com/jadugnap/golang-fpl-101/proto/pb"
+)
+
+// Registry maps player, team and position IDs to their names for the current season
+type Registry struct {
+	players   map[int]string
+	roles     map[int]int
+	teams     map[int]string
+	positions map[int]string
+}
+
+// New Registry from api/bootstrap-static/ elements, teams and element_types
+func New(players []team.Player, teams []team.Team, roles []team.PlayerRoles) *Registry {
+	r := &Registry{
+		players:   make(map[int]string, len(players)),
+		roles:     make(map[int]int, len(players)),
+		teams:     make(map[int]string, len(teams)),
+		positions: make(map[int]string, len(roles)),
+	}
+	for _, p := range players {
+		r.players[p.ID] = p.WebName
+		r.roles[p.ID] = p.RoleID
+	}
+	for _, t := range teams {
+		r.teams[t.ID] = t.ShortName
+	}
+	for _, role := range roles {
+		r.positions[role.ID] = role.ShortName
+	}
+	return r
+}
+
+// PlayerName by player ID, falls back to pb.Player_Webname_name without live data
+func (r *Registry) PlayerName(id int) string {
+	if r == nil || len(r.players) == 0 {
+		return pb.Player_Webname_name[int32(id)]
+	}
+	return r.players[id]
+}
+
+// TeamName by team ID, falls back to pb.Team_Shortname_name without live data
+func (r *Registry) TeamName(id int) string {
+	if r == nil || len(r.teams) == 0 {
+		return pb.Team_Shortname_name[int32(id)]
+	}
+	return r.teams[id]
+}
+
+// PositionName by element_type ID, falls back to pb.Player_Position_name without live data
+func (r *Registry) PositionName(id int) string {
+	if r == nil || len(r.positions) == 0 {
+		return pb.Player_Position_name[int32(id)]
+	}
+	return r.positions[id]
+}
+
+// PlayerPosition name by player ID, empty without live data
+func (r *Registry) PlayerPosition(id int) string {
+	if r == nil {
+		return ""
+	}
+	if roleID, ok := r.roles[id]; ok {
+		return r.PositionName(roleID)
+	}
+	return ""
+}
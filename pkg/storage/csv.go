@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+)
+
+// CSV Store of tables into csv.StructSlice files, Prefixes naming the file of each table.
+// A table without a prefix is skipped, e.g. one already exported in another shape.
+type CSV struct {
+	Prefixes map[string]string
+}
+
+// Save rows of table into its csv file
+func (c CSV) Save(table string, rows interface{}) error {
+	prefix, ok := c.Prefixes[table]
+	if !ok {
+		return nil
+	}
+	return csv.StructSlice(rows, prefix)
+}
+
+// Close has nothing to release
+func (c CSV) Close() error {
+	return nil
+}
+
+// Multi Store saving into each of its stores in turn
+type Multi []Store
+
+// Save rows of table into every store, stopping at the first error
+func (m Multi) Save(table string, rows interface{}) error {
+	for _, s := range m {
+		if err := s.Save(table, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close every store, the first error returned
+func (m Multi) Close() error {
+	var first error
+	for _, s := range m {
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
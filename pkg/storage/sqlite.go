@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	// pure Go sqlite driver, no cgo needed
+	_ "modernc.org/sqlite"
+)
+
+// SQLite Store in a single database file, tables gaining columns as structs gain fields
+type SQLite struct {
+	db    *sql.DB
+	runAt string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// OpenSQLite database at path, recording runAt in its runs table
+func OpenSQLite(path string, runAt time.Time) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	s := &SQLite{db: db, runAt: runAt.UTC().Format(time.RFC3339)}
+	if _, err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS runs (%v TEXT PRIMARY KEY)", RunColumn)); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(fmt.Sprintf("INSERT OR IGNORE INTO runs (%v) VALUES (?)", RunColumn), s.runAt); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save rows into table in one transaction
+func (s *SQLite) Save(table string, rows interface{}) error {
+	cols, err := columns(rows)
+	if err != nil {
+		return err
+	}
+	table = tableName(table)
+	if err := s.ensureTable(table, cols); err != nil {
+		return fmt.Errorf("table %v: %w", table, err)
+	}
+
+	names := []string{RunColumn}
+	marks := []string{"?"}
+	for _, c := range cols {
+		names = append(names, quote(c.name))
+		marks = append(marks, "?")
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v)", quote(table), strings.Join(names, ", "), strings.Join(marks, ", ")))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	v := reflect.ValueOf(rows)
+	for i := 0; i < v.Len(); i++ {
+		args := []interface{}{s.runAt}
+		for _, c := range cols {
+			arg, err := sqlValue(v.Index(i).Field(c.index))
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("table %v column %v: %w", table, c.name, err)
+			}
+			args = append(args, arg)
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close the database
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// LoadSQLite rows saved into table by the run at runAt of the database at path, rows
+// pointing to a slice of the structs saved. Columns without a field are skipped.
+func LoadSQLite(path, table, runAt string, rows interface{}) error {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("storage rows must point to a slice of structs, got %T", rows)
+	}
+	cols, err := columns(v.Elem().Interface())
+	if err != nil {
+		return err
+	}
+	name2Col := make(map[string]column, len(cols))
+	for _, c := range cols {
+		name2Col[c.name] = c
+	}
+	// sql.Open would create a missing database
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	sqlRows, err := db.Query(fmt.Sprintf("SELECT * FROM %v WHERE %v = ?", quote(tableName(table)), RunColumn), runAt)
+	if err != nil {
+		return err
+	}
+	defer sqlRows.Close()
+	names, err := sqlRows.Columns()
+	if err != nil {
+		return err
+	}
+	slice := v.Elem()
+	for sqlRows.Next() {
+		values := make([]interface{}, len(names))
+		pointers := make([]interface{}, len(names))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := sqlRows.Scan(pointers...); err != nil {
+			return err
+		}
+		row := reflect.New(slice.Type().Elem()).Elem()
+		for i, name := range names {
+			c, ok := name2Col[name]
+			if !ok || values[i] == nil {
+				continue
+			}
+			if err := setValue(row.Field(c.index), values[i]); err != nil {
+				return fmt.Errorf("table %v column %v: %w", table, name, err)
+			}
+		}
+		slice = reflect.Append(slice, row)
+	}
+	v.Elem().Set(slice)
+	return sqlRows.Err()
+}
+
+// ensureTable with every column of cols, adding those missing from an earlier run
+func (s *SQLite) ensureTable(table string, cols []column) error {
+	defs := []string{RunColumn + " TEXT NOT NULL"}
+	for _, c := range cols {
+		defs = append(defs, quote(c.name)+" "+c.kind)
+	}
+	if _, err := s.db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v (%v)", quote(table), strings.Join(defs, ", "))); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %v ON %v (%v)", quote(table+"_"+RunColumn), quote(table), RunColumn)); err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	infoRows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%v)", quote(table)))
+	if err != nil {
+		return err
+	}
+	defer infoRows.Close()
+	for infoRows.Next() {
+		var cid, notNull, pk int
+		var name, kind string
+		var defaultValue sql.NullString
+		if err := infoRows.Scan(&cid, &name, &kind, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		existing[name] = true
+	}
+	if err := infoRows.Err(); err != nil {
+		return err
+	}
+	for _, c := range cols {
+		if existing[c.name] {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v %v", quote(table), quote(c.name), c.kind)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlType of a struct field, json TEXT for anything but a scalar or a time
+func sqlType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "INTEGER"
+	case reflect.Float32, reflect.Float64:
+		return "REAL"
+	}
+	return "TEXT"
+}
+
+// sqlValue of a struct field for its sqlType column
+func sqlValue(v reflect.Value) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).UTC().Format(time.RFC3339), nil
+	}
+	jsonBytes, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return string(jsonBytes), nil
+}
+
+// setValue of a struct field from its sqlValue read back, the reverse of sqlValue
+func setValue(v reflect.Value, value interface{}) error {
+	text := ""
+	switch value := value.(type) {
+	case string:
+		text = value
+	case []byte:
+		text = string(value)
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		n, ok := value.(int64)
+		v.SetBool(ok && n != 0)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := value.(int64); ok {
+			v.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := value.(int64); ok {
+			v.SetUint(uint64(n))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := value.(type) {
+		case float64:
+			v.SetFloat(n)
+			return nil
+		case int64:
+			v.SetFloat(float64(n))
+			return nil
+		}
+	case reflect.String:
+		v.SetString(text)
+		return nil
+	default:
+		if v.Type() == timeType {
+			t, err := time.Parse(time.RFC3339, text)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(t))
+			return nil
+		}
+		return json.Unmarshal([]byte(text), v.Addr().Interface())
+	}
+	return fmt.Errorf("unexpected %T for %v", value, v.Type())
+}
+
+// quote an identifier
+func quote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,54 @@
+// Package storage provides a queryable store of scraped data across runs, every row
+// keyed by the time of the run that saved it
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Store of rows of a run into tables
+type Store interface {
+	// Save rows, a slice of structs, into table, created from the struct fields on first use
+	Save(table string, rows interface{}) error
+	Close() error
+}
+
+// RunColumn of every table, the time of the run in RFC 3339
+const RunColumn = "run_at"
+
+// column of a table from a struct field
+type column struct {
+	name  string
+	index int
+	kind  string
+}
+
+// columns of the struct type of rows, an error unless rows is a slice of structs
+func columns(rows interface{}) ([]column, error) {
+	t := reflect.TypeOf(rows)
+	if t == nil || t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("storage rows must be a slice of structs, got %T", rows)
+	}
+	cols := []column{}
+	for i := 0; i < t.Elem().NumField(); i++ {
+		f := t.Elem().Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		cols = append(cols, column{name: f.Name, index: i, kind: sqlType(f.Type)})
+	}
+	return cols, nil
+}
+
+// tableName of a csv-like prefix, e.g. "fpl-players/allteam" into "fpl_players_allteam"
+func tableName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,135 @@
+package storage_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jadugnap/golang-fpl-101/pkg/csv"
+	"github.com/jadugnap/golang-fpl-101/pkg/storage"
+)
+
+type player struct {
+	ID      int
+	WebName string
+	NowCost int
+}
+
+type playerV2 struct {
+	ID      int
+	WebName string
+	NowCost int
+	Form    float64
+}
+
+func TestStores(t *testing.T) {
+	csv.Dir = t.TempDir()
+	defer func() { csv.Dir = "csv_out" }()
+	dbPath := filepath.Join(t.TempDir(), "fpl.db")
+	runs := []time.Time{time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC), time.Date(2026, 8, 2, 10, 0, 0, 0, time.UTC)}
+
+	for i, runAt := range runs {
+		sqliteStore, err := storage.OpenSQLite(dbPath, runAt)
+		if err != nil {
+			t.Fatalf("OpenSQLite() error = %v", err)
+		}
+		store := storage.Multi{storage.CSV{Prefixes: map[string]string{"players": "fpl-players/allteam"}}, sqliteStore}
+		tables := []struct {
+			name string
+			rows interface{}
+		}{
+			{"players", []player{{ID: 1, WebName: "a", NowCost: 50 + i}, {ID: 2, WebName: "b", NowCost: 60}}},
+			{"teams", []struct{ ID int }{{ID: 1}}},
+		}
+		if i > 0 {
+			// a new field becomes a new column
+			tables[0].rows = []playerV2{{ID: 1, WebName: "a", NowCost: 50 + i, Form: 2.5}, {ID: 2, WebName: "b", NowCost: 60}}
+		}
+		for _, table := range tables {
+			if err := store.Save(table.name, table.rows); err != nil {
+				t.Fatalf("Save(%v) error = %v", table.name, err)
+			}
+		}
+		if err := store.Save("players", []int{1}); err == nil {
+			t.Errorf("Save() of a slice of ints, want an error")
+		}
+		if err := store.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(csv.Dir, "*", "*.csv"))
+	teamFiles, _ := filepath.Glob(filepath.Join(csv.Dir, "*.csv"))
+	if len(files) != 1 || len(teamFiles) != 0 {
+		t.Errorf("csv files %v and %v, want players only", files, teamFiles)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "SELECT COUNT(*) FROM runs", want: 2},
+		{query: "SELECT COUNT(*) FROM players", want: 4},
+		{query: "SELECT COUNT(*) FROM teams", want: 2},
+		{query: "SELECT NowCost FROM players WHERE ID = 1 AND run_at = '2026-08-02T10:00:00Z'", want: 51},
+		// rows saved before the column existed
+		{query: "SELECT COUNT(*) FROM players WHERE Form IS NULL", want: 2},
+	}
+	for _, tt := range tests {
+		got := 0
+		if err := db.QueryRow(tt.query).Scan(&got); err != nil {
+			t.Errorf("%v: %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSQLite(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "fpl.db")
+	runs := []time.Time{time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC), time.Date(2026, 8, 2, 10, 0, 0, 0, time.UTC)}
+	saved := [][]playerV2{
+		{{ID: 1, WebName: "a", NowCost: 50}},
+		{{ID: 1, WebName: "a", NowCost: 51, Form: 2.5}, {ID: 2, WebName: "b", NowCost: 60}},
+	}
+	for i, runAt := range runs {
+		store, err := storage.OpenSQLite(dbPath, runAt)
+		if err != nil {
+			t.Fatalf("OpenSQLite() error = %v", err)
+		}
+		if err := store.Save("players", saved[i]); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		store.Close()
+	}
+
+	for i, runAt := range runs {
+		got := []playerV2{}
+		if err := storage.LoadSQLite(dbPath, "players", runAt.Format(time.RFC3339), &got); err != nil {
+			t.Fatalf("LoadSQLite() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, saved[i]) {
+			t.Errorf("LoadSQLite() at %v = %+v, want %+v", runAt, got, saved[i])
+		}
+	}
+	// columns without a field are skipped
+	got := []player{}
+	if err := storage.LoadSQLite(dbPath, "players", "2026-08-02T10:00:00Z", &got); err != nil || len(got) != 2 {
+		t.Errorf("LoadSQLite() into fewer fields = %+v, %v, want 2 players", got, err)
+	}
+	if err := storage.LoadSQLite(filepath.Join(t.TempDir(), "missing.db"), "players", "2026-08-02T10:00:00Z", &got); err == nil {
+		t.Errorf("LoadSQLite() of a missing database, want an error")
+	}
+	if err := storage.LoadSQLite(dbPath, "players", "2026-08-02T10:00:00Z", got); err == nil {
+		t.Errorf("LoadSQLite() into a slice, not a pointer, want an error")
+	}
+}
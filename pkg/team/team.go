@@ -31,9 +31,9 @@ type Team struct {
 type PlayerRoles struct {
 	ID int `json:"id"`
 	// ElementCount       int    `json:"element_count"`
-	// SquadMaxPlay       int    `json:"squad_max_play"`
-	// SquadMinPlay       int    `json:"squad_min_play"`
-	// SquadSelect        int    `json:"squad_select"`
+	SquadMaxPlay int `json:"squad_max_play"`
+	SquadMinPlay int `json:"squad_min_play"`
+	SquadSelect  int `json:"squad_select"`
 	// SubPositionsLocked []int  `json:"sub_positions_locked"`
 	// UIShirtSpecific    bool   `json:"ui_shirt_specific"`
 	// PluralName         string `json:"plural_name"`
@@ -55,10 +55,10 @@ type Player struct {
 	// ChanceOfPlayingThisRound         interface{} `json:"chance_of_playing_this_round"`
 	// CleanSheets                      int         `json:"clean_sheets"`
 	// Code                             int         `json:"code"`
-	// CostChangeEvent                  int         `json:"cost_change_event"`
-	// CostChangeEventFall              int         `json:"cost_change_event_fall"`
-	// CostChangeStart                  int         `json:"cost_change_start"`
-	// CostChangeStartFall              int         `json:"cost_change_start_fall"`
+	CostChangeEvent     int `json:"cost_change_event"`
+	CostChangeEventFall int `json:"cost_change_event_fall"`
+	CostChangeStart     int `json:"cost_change_start"`
+	CostChangeStartFall int `json:"cost_change_start_fall"`
 	// CornersAndIndirectFreekicksOrder int         `json:"corners_and_indirect_freekicks_order"`
 	// CornersAndIndirectFreekicksText  string      `json:"corners_and_indirect_freekicks_text"`
 	// Creativity                       string      `json:"creativity"`
@@ -67,8 +67,8 @@ type Player struct {
 	// DirectFreekicksOrder             interface{} `json:"direct_freekicks_order"`
 	// DirectFreekicksText              string      `json:"direct_freekicks_text"`
 	// DreamteamCount                   int         `json:"dreamteam_count"`
-	// EpNext                           string      `json:"ep_next"`
-	// EpThis                           string      `json:"ep_this"`
+	EpNext string `json:"ep_next"`
+	EpThis string `json:"ep_this"`
 	// EventPoints                      int         `json:"event_points"`
 	// FirstName                        string      `json:"first_name"`
 	// GoalsConceded                    int         `json:"goals_conceded"`
@@ -79,12 +79,12 @@ type Player struct {
 	// Influence                        string      `json:"influence"`
 	// InfluenceRank                    int         `json:"influence_rank"`
 	// InfluenceRankType                int         `json:"influence_rank_type"`
-	// News                             string      `json:"news"`
+	News string `json:"news"`
 	// NewsAdded                        interface{} `json:"news_added"`
 	// Photo                            string      `json:"photo"`
 	// Special                          bool        `json:"special"`
 	// SquadNumber                      interface{} `json:"squad_number"`
-	// Status                           string      `json:"status"`
+	Status string `json:"status"`
 	// OwnGoals                         int         `json:"own_goals"`
 	// PenaltiesMissed                  int         `json:"penalties_missed"`
 	// PenaltiesOrder                   interface{} `json:"penalties_order"`
@@ -97,23 +97,20 @@ type Player struct {
 	// Threat                           string      `json:"threat"`
 	// ThreatRank                       int         `json:"threat_rank"`
 	// ThreatRankType                   int         `json:"threat_rank_type"`
-	// TransfersInEvent                 int         `json:"transfers_in_event"`
-	// TransfersOutEvent                int         `json:"transfers_out_event"`
+	TransfersInEvent  int `json:"transfers_in_event"`
+	TransfersOutEvent int `json:"transfers_out_event"`
 	// YellowCards                      int         `json:"yellow_cards"`
-	// SelectedByPercent                string      `json:"selected_by_percent"`
+	SelectedByPercent string `json:"selected_by_percent"`
 	// TransfersIn                      int         `json:"transfers_in"`
 	// TransfersOut                     int         `json:"transfers_out"`
 	// Bonus                            int         `json:"bonus"`
 	// Bps                              int         `json:"bps"`
-	PlayerCount        int
-	RegularPlayerCount int
-	PointsPerGame      string `json:"points_per_game"`
-	OppPointsPerGame   string
-	Form               string `json:"form"`
-	TotalPoints        int    `json:"total_points"`
-	ValueForm          string `json:"value_form"`
-	ValueSeason        string `json:"value_season"`
-	IctIndex           string `json:"ict_index"`
-	NowCost            int    `json:"now_cost"`
-	Minutes            int    `json:"minutes"`
+	PointsPerGame string `json:"points_per_game"`
+	Form          string `json:"form"`
+	TotalPoints   int    `json:"total_points"`
+	ValueForm     string `json:"value_form"`
+	ValueSeason   string `json:"value_season"`
+	IctIndex      string `json:"ict_index"`
+	NowCost       int    `json:"now_cost"`
+	Minutes       int    `json:"minutes"`
 }